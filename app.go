@@ -4,12 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"go-twitter-follower/gen"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type App struct {
@@ -18,6 +22,11 @@ type App struct {
 	config            *Config
 	mu                sync.Mutex
 	selectedAccountID string
+	stopWorkers       context.CancelFunc
+
+	loginMu       sync.Mutex
+	pendingLogin  *OAuth1Login
+	pendingOAuth2 *OAuth2Login
 }
 
 type FollowingUser struct {
@@ -35,6 +44,7 @@ type FollowingUser struct {
 	Location        string   `json:"location"`
 	CreatedAt       string   `json:"created_at"`
 	UpdatedAt       string   `json:"updated_at"`
+	StabilityScore  float64  `json:"stability_score"`
 	Lists           []string `json:"lists,omitempty"`
 }
 
@@ -49,19 +59,47 @@ func NewApp() *App {
 }
 
 func (a *App) startup(ctx context.Context) {
+	if err := a.initCore(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	a.stopWorkers = cancel
+	go a.runFollowQueue(workerCtx)
+	go a.runMetricsCheck(workerCtx)
+	go a.runTokenRefresh(workerCtx)
+}
+
+// initCore opens the database and loads accounts; shared by the GUI and the CLI,
+// which runs without the background workers.
+func (a *App) initCore(ctx context.Context) error {
 	a.ctx = ctx
 	a.config = GetConfig()
-	a.db = InitDB()
+	db, err := InitDB()
+	if err != nil {
+		return err
+	}
+	a.db = db
+	SetAPIBaseURL(a.config.APIBaseURL)
+	// Never fall through to the live API when a replay was asked for.
+	if err := ConfigureCassette(a.config.CassettePath, a.config.CassetteMode); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	rateLimits.SetDB(a.db)
+	spendLedger.SetDB(a.db)
+	userTokens.Configure(a.db, OAuth2ConfigFromConfig(a.config))
 
 	// Auto-import .env account if configured
 	if a.config.BearerToken != "" && a.config.Username != "" {
 		userId := a.config.UserId
 		if userId == "" {
+			since := time.Now().UTC().Format(time.RFC3339)
 			client, err := NewAuthClient(a.config.BearerToken)
 			if err == nil {
-				resolved, err := ResolveUsername(client, a.config.Username)
+				resolved, err := ResolveUsername(a.ctx, client, a.config.Username)
 				if err == nil {
 					userId = resolved
+					AttributeAPICalls(a.db, a.config.BearerToken, userId, since)
 				}
 			}
 		}
@@ -78,9 +116,13 @@ func (a *App) startup(ctx context.Context) {
 			a.selectedAccountID = accounts[0].UserID
 		}
 	}
+	return nil
 }
 
 func (a *App) shutdown(ctx context.Context) {
+	if a.stopWorkers != nil {
+		a.stopWorkers()
+	}
 	if a.db != nil {
 		a.db.Close()
 	}
@@ -106,15 +148,23 @@ func (a *App) SelectAccount(userID string) {
 }
 
 func (a *App) AddNewAccount(username, bearerToken string) (string, error) {
+	since := time.Now().UTC().Format(time.RFC3339)
 	client, err := NewAuthClient(bearerToken)
 	if err != nil {
 		return "", fmt.Errorf("invalid bearer token: %w", err)
 	}
 
-	userId, err := ResolveUsername(client, username)
+	user, err := LookupUser(a.ctx, client, username)
 	if err != nil {
 		return "", fmt.Errorf("could not resolve @%s: %w", username, err)
 	}
+	userId := user.Id
+	if err := UpsertUser(a.db, *user); err != nil {
+		log.Printf("Warning: failed to upsert user %s: %v", userId, err)
+	}
+	if err := AttributeAPICalls(a.db, bearerToken, userId, since); err != nil {
+		log.Printf("Warning: failed to attribute lookup of @%s: %v", username, err)
+	}
 
 	if err := AddAccount(a.db, userId, username, bearerToken); err != nil {
 		return "", fmt.Errorf("failed to save account: %w", err)
@@ -144,6 +194,127 @@ func (a *App) RemoveAccountByID(userID string) error {
 	return nil
 }
 
+// GetRateLimits returns the last observed X API rate limits per endpoint and token.
+func (a *App) GetRateLimits() []RateLimit {
+	return rateLimits.Snapshot()
+}
+
+// --- API spend ---
+
+func (a *App) GetSpendByDay() []SpendRow {
+	return a.spendSummary(spendByDay, time.Now().UTC().AddDate(0, 0, -30))
+}
+
+func (a *App) GetSpendByMonth() []SpendRow {
+	return a.spendSummary(spendByMonth, time.Now().UTC().AddDate(-1, 0, 0))
+}
+
+// GetSpendByAccount returns this month's spend per account user ID.
+func (a *App) GetSpendByAccount() []SpendRow {
+	return a.spendSummary(spendByAccount, startOfMonth(time.Now().UTC()))
+}
+
+// GetSpendByEndpoint returns this month's spend per endpoint.
+func (a *App) GetSpendByEndpoint() []SpendRow {
+	return a.spendSummary(spendByEndpoint, startOfMonth(time.Now().UTC()))
+}
+
+func (a *App) spendSummary(keyExpr string, since time.Time) []SpendRow {
+	rows, err := GetSpendSummary(a.db, keyExpr, since.Format(time.RFC3339))
+	if err != nil {
+		log.Printf("Error getting spend: %v", err)
+		return nil
+	}
+	return rows
+}
+
+func (a *App) GetPriceTable() []APIPrice {
+	prices, err := GetPrices(a.db)
+	if err != nil {
+		log.Printf("Error getting prices: %v", err)
+		return nil
+	}
+	return prices
+}
+
+func (a *App) SetPrice(endpoint string, unitCost float64) error {
+	if unitCost < 0 {
+		return fmt.Errorf("unit cost must not be negative")
+	}
+	return SetPrice(a.db, endpoint, unitCost)
+}
+
+// --- Budgets ---
+
+func (a *App) GetBudgets() []Budget {
+	budgets, err := GetBudgets(a.db)
+	if err != nil {
+		log.Printf("Error getting budgets: %v", err)
+		return nil
+	}
+	return budgets
+}
+
+// SetBudget sets the monthly budget in USD for an account, or the global
+// budget when accountUserID is "". A limit of 0 removes the budget.
+func (a *App) SetBudget(accountUserID string, monthlyLimit float64, truncate bool) error {
+	if monthlyLimit < 0 {
+		return fmt.Errorf("monthly limit must not be negative")
+	}
+	if monthlyLimit == 0 {
+		return DeleteBudget(a.db, accountUserID)
+	}
+	return SetBudget(a.db, Budget{Scope: accountUserID, MonthlyLimit: monthlyLimit, Truncate: truncate})
+}
+
+// --- Cost estimates (dry run, no API calls) ---
+
+func (a *App) EstimateFetchFollowing() CostEstimate {
+	return a.estimateUserFetch("following", endpointFollowing, IsFollowingCacheFresh)
+}
+
+func (a *App) EstimateFetchFollowers() CostEstimate {
+	return a.estimateUserFetch("followers", endpointFollowers, IsFollowersCacheFresh)
+}
+
+func (a *App) estimateUserFetch(action, endpoint string, isFresh func(*sql.DB, string) bool) CostEstimate {
+	est := CostEstimate{Action: action, Known: true}
+	if a.selectedAccountID == "" {
+		return est
+	}
+
+	est.add(EstimateUserFetch(a.db, endpoint, a.selectedAccountID, a.selectedAccountID), isFresh(a.db, a.selectedAccountID))
+	est.Budget = CheckBudget(a.db, a.selectedAccountID, FetchEstimate{Endpoint: endpoint, Items: est.Items, Cost: est.Cost, Known: est.Known})
+	return est
+}
+
+// EstimateFetchLists mirrors FetchListsNow: one owned_lists call when the list
+// cache is stale, plus member pages for every list whose member cache is stale.
+func (a *App) EstimateFetchLists() CostEstimate {
+	est := CostEstimate{Action: "lists", Known: true}
+	if a.selectedAccountID == "" {
+		return est
+	}
+
+	lists := GetCachedLists(a.db, a.selectedAccountID)
+	owned := FetchEstimate{
+		Endpoint: endpointOwnedLists,
+		TargetID: a.selectedAccountID,
+		Items:    len(lists),
+		Pages:    1,
+		Cost:     float64(len(lists)) * GetUnitPrice(a.db, endpointOwnedLists),
+		Known:    len(lists) > 0,
+	}
+	est.add(owned, IsListCacheFresh(a.db, a.selectedAccountID))
+
+	for _, l := range lists {
+		est.add(EstimateUserFetch(a.db, endpointListMembers, a.selectedAccountID, l.Id), IsListMemberCacheFresh(a.db, l.Id))
+	}
+
+	est.Budget = CheckBudget(a.db, a.selectedAccountID, FetchEstimate{Endpoint: endpointListMembers, Items: est.Items, Cost: est.Cost, Known: est.Known})
+	return est
+}
+
 // --- Lists (cache-aware) ---
 
 type TwitterList struct {
@@ -155,8 +326,12 @@ type TwitterList struct {
 }
 
 func (a *App) GetListsStats() Stats {
+	return a.listsStats(a.selectedAccountID)
+}
+
+func (a *App) listsStats(accountID string) Stats {
 	var stats Stats
-	if a.selectedAccountID == "" {
+	if accountID == "" {
 		return stats
 	}
 
@@ -164,12 +339,12 @@ func (a *App) GetListsStats() Stats {
 		SELECT COUNT(DISTINCT lmc.user_id)
 		FROM list_member_cache lmc
 		JOIN list_cache lc ON lmc.list_id = lc.list_id AND lc.owner_user_id = ?
-	`, a.selectedAccountID).Scan(&stats.TotalCount)
+	`, accountID).Scan(&stats.TotalCount)
 
 	var fetchedAt string
 	a.db.QueryRow(`
 		SELECT COALESCE(MAX(fetched_at), '') FROM list_cache WHERE owner_user_id = ?
-	`, a.selectedAccountID).Scan(&fetchedAt)
+	`, accountID).Scan(&fetchedAt)
 	if fetchedAt != "" {
 		stats.LastFetchAt = fetchedAt
 		if t, err := time.Parse(time.RFC3339, fetchedAt); err == nil {
@@ -187,20 +362,14 @@ func (a *App) GetOwnedLists() []TwitterList {
 	return GetCachedLists(a.db, a.selectedAccountID)
 }
 
-func (a *App) fetchAndCacheOwnedLists() []TwitterList {
-	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
-	if err != nil {
-		log.Printf("Error getting account: %v", err)
-		return nil
-	}
-
-	client, err := NewAuthClient(acct.BearerToken)
+func (a *App) fetchAndCacheOwnedLists(ctx context.Context, acct Account) []TwitterList {
+	client, err := NewAccountClient(acct)
 	if err != nil {
 		log.Printf("Error creating client: %v", err)
 		return nil
 	}
 
-	lists, err := GetOwnedLists(client, a.selectedAccountID)
+	lists, err := GetOwnedLists(ctx, client, acct.UserID)
 	if err != nil {
 		log.Printf("Error fetching owned lists: %v", err)
 		return nil
@@ -224,7 +393,7 @@ func (a *App) fetchAndCacheOwnedLists() []TwitterList {
 		result = append(result, tl)
 	}
 
-	if err := SaveListCache(a.db, a.selectedAccountID, result); err != nil {
+	if err := SaveListCache(a.db, acct.UserID, result); err != nil {
 		log.Printf("Warning: failed to save list cache: %v", err)
 	}
 
@@ -235,57 +404,64 @@ func (a *App) GetListMembers(listId string) []FollowingUser {
 	if a.selectedAccountID == "" {
 		return nil
 	}
-	return a.getListMembersFromCache(listId)
+	return a.getListMembersFromCache(a.selectedAccountID, listId)
 }
 
-func (a *App) getListMembersFromCache(listId string) []FollowingUser {
+func (a *App) getListMembersFromCache(accountID, listId string) []FollowingUser {
 	ids := GetCachedListMemberIDs(a.db, listId)
 	users, err := GetUsersByIDs(a.db, ids)
 	if err != nil {
 		log.Printf("Error getting cached list members: %v", err)
 		return nil
 	}
-	return a.enrichWithListNames(users)
+	return a.enrichWithListNames(accountID, users)
 }
 
-func (a *App) fetchAndCacheListMembers(listId string) []FollowingUser {
-	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
-	if err != nil {
-		log.Printf("Error getting account: %v", err)
-		return nil
+// fetchAndCacheListMembers fetches and caches listId's members, reading at
+// most maxItems when maxItems > 0. It returns how many members this run read
+// and an error unless the list's job completed.
+func (a *App) fetchAndCacheListMembers(ctx context.Context, acct Account, listId string, maxItems int) (int, error) {
+	budget := CheckBudget(a.db, acct.UserID, EstimateUserFetch(a.db, endpointListMembers, acct.UserID, listId))
+	if budget.Message != "" {
+		log.Printf("[budget] list %s: %s", listId, budget.Message)
+	}
+	if !budget.Allowed {
+		return 0, errors.New(budget.Message)
 	}
 
-	client, err := NewAuthClient(acct.BearerToken)
+	client, err := NewAccountClient(acct)
 	if err != nil {
 		log.Printf("Error creating client: %v", err)
-		return nil
+		return 0, err
 	}
 
-	users, err := FetchAllListMembers(client, listId)
+	p := NewListMembersPaginator(client, listId)
+	p.MaxItems = budget.MaxItems
+	if maxItems > 0 && (p.MaxItems == 0 || maxItems < p.MaxItems) {
+		p.MaxItems = maxItems
+	}
+	before := 0
+	if job, err := GetFetchJob(a.db, endpointListMembers, acct.UserID, listId); err == nil {
+		before = job.Items
+	}
+	job, complete, err := a.runFetchJob(ctx, endpointListMembers, acct.UserID, listId, p)
 	if err != nil {
 		log.Printf("Error fetching list members: %v", err)
-		return nil
+		return 0, fmt.Errorf("%w (progress saved, Fetch Now resumes)", err)
 	}
-
-	var userIDs []string
-	var result []FollowingUser
-	for _, u := range users {
-		if err := UpsertUser(a.db, u); err != nil {
-			log.Printf("Warning: failed to upsert user %s: %v", u.Id, err)
-		}
-		userIDs = append(userIDs, u.Id)
-		fu := genUserToFollowingUser(u)
-		result = append(result, fu)
+	read := job.Items - before
+	if !complete {
+		return read, fmt.Errorf("stopped at budget cap after %d members; Fetch Now resumes", job.Items)
 	}
 
-	if err := SaveListMemberCache(a.db, listId, userIDs); err != nil {
+	if _, err := CompleteFetchJobListMembers(a.db, job.ID, listId); err != nil {
 		log.Printf("Warning: failed to save list member cache: %v", err)
+		return read, err
 	}
-
-	return a.enrichWithListNames(result)
+	return read, nil
 }
 
-func (a *App) enrichWithListNames(users []FollowingUser) []FollowingUser {
+func (a *App) enrichWithListNames(accountID string, users []FollowingUser) []FollowingUser {
 	if len(users) == 0 {
 		return users
 	}
@@ -293,7 +469,7 @@ func (a *App) enrichWithListNames(users []FollowingUser) []FollowingUser {
 	for i, u := range users {
 		ids[i] = u.Id
 	}
-	listMap := GetListNamesForUsers(a.db, a.selectedAccountID, ids)
+	listMap := GetListNamesForUsers(a.db, accountID, ids)
 	if listMap == nil {
 		return users
 	}
@@ -308,7 +484,11 @@ func (a *App) enrichWithListNames(users []FollowingUser) []FollowingUser {
 // --- Followers ---
 
 func (a *App) GetFollowersList() []FollowingUser {
-	if a.selectedAccountID == "" {
+	return a.followersList(a.selectedAccountID)
+}
+
+func (a *App) followersList(accountID string) []FollowingUser {
+	if accountID == "" {
 		return nil
 	}
 
@@ -318,16 +498,18 @@ func (a *App) GetFollowersList() []FollowingUser {
 			COALESCE(u.tweet_count, 0), COALESCE(u.listed_count, 0),
 			COALESCE(u.verified, 0), COALESCE(u.verified_type, ''),
 			COALESCE(u.profile_image_url, ''), COALESCE(u.location, ''),
-			COALESCE(u.created_at, ''), COALESCE(u.updated_at, '')
+			COALESCE(u.created_at, ''), COALESCE(u.updated_at, ''),
+			COALESCE(cs.score, 0)
 		FROM users u
 		INNER JOIN followers_snapshots fs ON u.id = fs.target_user_id
+		LEFT JOIN content_scores cs ON cs.user_id = u.id
 		WHERE fs.source_user_id = ?
 		  AND fs.fetched_at = (
 			  SELECT MAX(fetched_at) FROM followers_snapshots
 			  WHERE source_user_id = ?
 		  )
 		ORDER BY u.followers_count DESC
-	`, a.selectedAccountID, a.selectedAccountID)
+	`, accountID, accountID)
 	if err != nil {
 		log.Printf("Error querying followers: %v", err)
 		return nil
@@ -341,7 +523,7 @@ func (a *App) GetFollowersList() []FollowingUser {
 		err := rows.Scan(&u.Id, &u.Username, &u.Name, &u.Description,
 			&u.FollowersCount, &u.FollowingCount, &u.TweetCount, &u.ListedCount,
 			&verified, &u.VerifiedType, &u.ProfileImageUrl, &u.Location,
-			&u.CreatedAt, &u.UpdatedAt)
+			&u.CreatedAt, &u.UpdatedAt, &u.StabilityScore)
 		if err != nil {
 			log.Printf("Error scanning follower: %v", err)
 			continue
@@ -353,8 +535,12 @@ func (a *App) GetFollowersList() []FollowingUser {
 }
 
 func (a *App) GetFollowersStats() Stats {
+	return a.followersStats(a.selectedAccountID)
+}
+
+func (a *App) followersStats(accountID string) Stats {
 	var stats Stats
-	if a.selectedAccountID == "" {
+	if accountID == "" {
 		return stats
 	}
 
@@ -362,12 +548,12 @@ func (a *App) GetFollowersStats() Stats {
 		SELECT COUNT(*) FROM followers_snapshots
 		WHERE source_user_id = ?
 		  AND fetched_at = (SELECT MAX(fetched_at) FROM followers_snapshots WHERE source_user_id = ?)
-	`, a.selectedAccountID, a.selectedAccountID).Scan(&stats.TotalCount)
+	`, accountID, accountID).Scan(&stats.TotalCount)
 
 	var fetchedAt string
 	a.db.QueryRow(`
 		SELECT COALESCE(MAX(fetched_at), '') FROM followers_snapshots WHERE source_user_id = ?
-	`, a.selectedAccountID).Scan(&fetchedAt)
+	`, accountID).Scan(&fetchedAt)
 	if fetchedAt != "" {
 		stats.LastFetchAt = fetchedAt
 		if t, err := time.Parse(time.RFC3339, fetchedAt); err == nil {
@@ -388,271 +574,1170 @@ func (a *App) FetchFollowersNow() string {
 		return "Account not found."
 	}
 
+	return statusMessage(a.fetchFollowersForAccount(a.ctx, *acct))
+}
+
+func (a *App) fetchFollowersForAccount(ctx context.Context, acct Account) (string, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	if IsFollowersCacheFresh(a.db, acct.UserID) {
 		msg := fmt.Sprintf("Cache fresh for @%s followers, skipping API call", acct.Username)
 		log.Println(msg)
-		return msg
+		return msg, nil
+	}
+
+	budget := CheckBudget(a.db, acct.UserID, EstimateUserFetch(a.db, endpointFollowers, acct.UserID, acct.UserID))
+	if budget.Message != "" {
+		log.Printf("[budget] @%s: %s", acct.Username, budget.Message)
+	}
+	if !budget.Allowed {
+		return "", errors.New(budget.Message)
 	}
 
 	log.Printf("[fetch] Fetching followers for @%s (user_id=%s)", acct.Username, acct.UserID)
-	client, err := NewAuthClient(acct.BearerToken)
+	client, err := NewAccountClient(acct)
 	if err != nil {
-		msg := fmt.Sprintf("Error for @%s: %v", acct.Username, err)
-		log.Println(msg)
-		return msg
+		log.Printf("Error for @%s: %v", acct.Username, err)
+		return "", fmt.Errorf("error for @%s: %w", acct.Username, err)
 	}
 
-	all, err := FetchAllFollowers(client, acct.UserID)
+	p := NewFollowersPaginator(client, acct.UserID)
+	p.MaxItems = budget.MaxItems
+	job, complete, err := a.runFetchJob(ctx, endpointFollowers, acct.UserID, acct.UserID, p)
 	if err != nil {
-		msg := fmt.Sprintf("Fetch error for @%s: %v", acct.Username, err)
-		log.Println(msg)
-		return msg
+		log.Printf("Fetch error for @%s: %v", acct.Username, err)
+		return "", fmt.Errorf("fetch error for @%s: %w (progress saved, Fetch Now resumes)", acct.Username, err)
 	}
-
-	for _, user := range all {
-		if err := UpsertUser(a.db, user); err != nil {
-			log.Printf("Warning: failed to upsert user %s: %v", user.Id, err)
-		}
+	if !complete {
+		return fmt.Sprintf("Fetched %d followers for @%s, stopped at budget cap; Fetch Now resumes", job.Items, acct.Username), nil
 	}
-	if err := SaveFollowersSnapshot(a.db, acct.UserID, all); err != nil {
+
+	n, err := CompleteFetchJobSnapshot(a.db, job.ID, "followers_snapshots", acct.UserID)
+	if err != nil {
 		log.Printf("Warning: failed to save followers snapshot: %v", err)
 	}
-	LogFetch(a.db, "GET /2/users/:id/followers", acct.UserID, 200)
+	LogFetch(a.db, endpointFollowers, acct.UserID, 200)
 
-	msg := fmt.Sprintf("Fetched %d followers for @%s at %s", len(all), acct.Username, time.Now().Format("15:04:05"))
+	msg := fmt.Sprintf("Fetched %d followers for @%s at %s", n, acct.Username, time.Now().Format("15:04:05"))
 	log.Println(msg)
-	return msg
+	return msg, nil
 }
 
-// --- Fetching (manual only, no scheduler) ---
+// --- Follower changes ---
 
-// FetchNow fetches following for the currently selected account (manual trigger from UI).
-func (a *App) FetchNow() string {
+type SnapshotDiff struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Gained []FollowingUser `json:"gained"`
+	Lost   []FollowingUser `json:"lost"`
+}
+
+// GetFollowerSnapshots lists the followers snapshots of the selected account, newest first.
+func (a *App) GetFollowerSnapshots() []SnapshotInfo {
 	if a.selectedAccountID == "" {
-		return "No account selected. Add an account first."
+		return nil
 	}
-
-	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
+	snapshots, err := GetSnapshotTimestamps(a.db, "followers_snapshots", a.selectedAccountID)
 	if err != nil {
-		return "Account not found."
+		log.Printf("Error getting follower snapshots: %v", err)
+		return nil
 	}
+	return snapshots
+}
 
-	return a.fetchFollowingForAccount(*acct)
+// GetFollowersDiff compares two followers snapshots by fetched_at. Empty
+// from/to default to the previous and latest snapshot.
+func (a *App) GetFollowersDiff(from, to string) (SnapshotDiff, error) {
+	return a.snapshotDiff("followers_snapshots", from, to)
 }
 
-// FetchListsNow fetches owned lists + all members (with 30-day cache check).
-func (a *App) FetchListsNow() string {
+func (a *App) snapshotDiff(table, from, to string) (SnapshotDiff, error) {
+	diff := SnapshotDiff{From: from, To: to, Gained: []FollowingUser{}, Lost: []FollowingUser{}}
 	if a.selectedAccountID == "" {
-		return "No account selected. Add an account first."
+		return diff, nil
 	}
 
-	if IsListCacheFresh(a.db, a.selectedAccountID) {
-		msg := fmt.Sprintf("Cache fresh for lists, skipping API call")
-		log.Println(msg)
-		return msg
+	snapshots, err := GetSnapshotTimestamps(a.db, table, a.selectedAccountID)
+	if err != nil {
+		return diff, err
 	}
-
-	lists := a.fetchAndCacheOwnedLists()
-	if lists == nil {
-		return "Error fetching lists."
+	if from == "" || to == "" {
+		if len(snapshots) < 2 {
+			return diff, nil
+		}
+		if to == "" {
+			diff.To = snapshots[0].FetchedAt
+		}
+		if from == "" {
+			diff.From = snapshots[1].FetchedAt
+		}
 	}
-
-	for _, l := range lists {
-		a.fetchAndCacheListMembers(l.Id)
+	for _, ts := range []string{diff.From, diff.To} {
+		if !hasSnapshot(snapshots, ts) {
+			return diff, fmt.Errorf("no %s snapshot at %q", strings.TrimSuffix(table, "_snapshots"), ts)
+		}
 	}
 
-	return fmt.Sprintf("Fetched %d lists at %s", len(lists), time.Now().Format("15:04:05"))
+	gained, lost, err := GetSnapshotDiff(a.db, table, a.selectedAccountID, diff.From, diff.To)
+	if err != nil {
+		return diff, err
+	}
+	for _, side := range []struct {
+		ids  []string
+		dest *[]FollowingUser
+	}{{gained, &diff.Gained}, {lost, &diff.Lost}} {
+		users, err := GetUsersByIDs(a.db, side.ids)
+		if err != nil {
+			return diff, err
+		}
+		if users != nil {
+			*side.dest = a.enrichWithListNames(a.selectedAccountID, users)
+		}
+	}
+	return diff, nil
 }
 
-func (a *App) fetchFollowingForAccount(acct Account) string {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	if IsFollowingCacheFresh(a.db, acct.UserID) {
-		msg := fmt.Sprintf("Cache fresh for @%s, skipping API call", acct.Username)
-		log.Println(msg)
-		return msg
+func hasSnapshot(snapshots []SnapshotInfo, fetchedAt string) bool {
+	for _, s := range snapshots {
+		if s.FetchedAt == fetchedAt {
+			return true
+		}
 	}
+	return false
+}
 
-	log.Printf("[fetch] Fetching for @%s (user_id=%s, token=%s...)", acct.Username, acct.UserID, acct.BearerToken[:min(8, len(acct.BearerToken))])
-	client, err := NewAuthClient(acct.BearerToken)
-	if err != nil {
-		msg := fmt.Sprintf("Error for @%s: %v", acct.Username, err)
-		log.Println(msg)
-		return msg
+// GetUserTimeline returns when userID followed/unfollowed the selected
+// account and when the account followed/unfollowed them, across all snapshots.
+func (a *App) GetUserTimeline(userID string) (UserTimeline, error) {
+	if a.selectedAccountID == "" {
+		return UserTimeline{}, nil
 	}
-
-	all, err := FetchAllFollowing(client, acct.UserID)
+	tl, err := BuildUserTimeline(a.db, a.selectedAccountID, userID)
 	if err != nil {
-		msg := fmt.Sprintf("Fetch error for @%s: %v", acct.Username, err)
-		log.Println(msg)
-		return msg
+		return tl, err
+	}
+	if tl.User != nil {
+		tl.User.Lists = a.enrichWithListNames(a.selectedAccountID, []FollowingUser{*tl.User})[0].Lists
 	}
+	return tl, nil
+}
 
-	for _, user := range all {
-		if err := UpsertUser(a.db, user); err != nil {
-			log.Printf("Warning: failed to upsert user %s: %v", user.Id, err)
-		}
+// GetFollowerChurners lists followers who unfollowed at least once, e.g. to
+// spot accounts that follow for a follow-back and then leave.
+func (a *App) GetFollowerChurners() []ChurnUser {
+	if a.selectedAccountID == "" {
+		return nil
 	}
-	if err := SaveSnapshot(a.db, acct.UserID, all); err != nil {
-		log.Printf("Warning: failed to save snapshot: %v", err)
+	churners, err := FindFollowerChurners(a.db, a.selectedAccountID)
+	if err != nil {
+		log.Printf("Error finding churners: %v", err)
+		return nil
 	}
-	LogFetch(a.db, "GET /2/users/:id/following", acct.UserID, 200)
-
-	msg := fmt.Sprintf("Fetched %d for @%s at %s", len(all), acct.Username, time.Now().Format("15:04:05"))
-	log.Println(msg)
-	return msg
+	return churners
 }
 
-// --- Data queries (scoped to selectedAccountID) ---
+// --- Relationships ---
 
-func (a *App) GetFollowingList() []FollowingUser {
+// GetRelationshipBuckets classifies the latest following/followers snapshots
+// of the selected account into mutual, not-following-back and fans, each
+// sorted by sortBy/sortDir and enriched with list badges.
+func (a *App) GetRelationshipBuckets(sortBy, sortDir string) RelationshipBuckets {
 	if a.selectedAccountID == "" {
-		return nil
+		return RelationshipBuckets{}
 	}
 
-	rows, err := a.db.Query(`
-		SELECT u.id, u.username, COALESCE(u.name, ''), COALESCE(u.description, ''),
-			COALESCE(u.followers_count, 0), COALESCE(u.following_count, 0),
-			COALESCE(u.tweet_count, 0), COALESCE(u.listed_count, 0),
-			COALESCE(u.verified, 0), COALESCE(u.verified_type, ''),
-			COALESCE(u.profile_image_url, ''), COALESCE(u.location, ''),
-			COALESCE(u.created_at, ''), COALESCE(u.updated_at, '')
-		FROM users u
-		INNER JOIN following_snapshots fs ON u.id = fs.target_user_id
-		WHERE fs.source_user_id = ?
-		  AND fs.fetched_at = (
-			  SELECT MAX(fetched_at) FROM following_snapshots
-			  WHERE source_user_id = ?
-		  )
-		ORDER BY u.followers_count DESC
-	`, a.selectedAccountID, a.selectedAccountID)
+	b, err := ClassifyRelationships(a.db, a.selectedAccountID)
 	if err != nil {
-		log.Printf("Error querying users: %v", err)
-		return nil
+		log.Printf("Error classifying relationships: %v", err)
+		return RelationshipBuckets{}
 	}
-	defer rows.Close()
-
-	var users []FollowingUser
-	for rows.Next() {
-		var u FollowingUser
-		var verified int
-		err := rows.Scan(&u.Id, &u.Username, &u.Name, &u.Description,
-			&u.FollowersCount, &u.FollowingCount, &u.TweetCount, &u.ListedCount,
-			&verified, &u.VerifiedType, &u.ProfileImageUrl, &u.Location,
-			&u.CreatedAt, &u.UpdatedAt)
-		if err != nil {
-			log.Printf("Error scanning user: %v", err)
-			continue
-		}
-		u.Verified = verified == 1
-		users = append(users, u)
+	for _, users := range [][]FollowingUser{b.Mutual, b.NotFollowingBack, b.Fans} {
+		sortUsers(users, sortBy, sortDir)
+		a.enrichWithListNames(a.selectedAccountID, users)
 	}
-	return users
+	return b
 }
 
-func (a *App) GetStats() Stats {
-	var stats Stats
+// --- Follow actions (OAuth1 user context) ---
+
+// FollowUser follows userID on behalf of the selected account. The UI asks
+// for confirmation first; every request sent is recorded in follow_actions.
+func (a *App) FollowUser(userID string) (FollowAction, error) {
 	if a.selectedAccountID == "" {
-		return stats
+		return FollowAction{}, fmt.Errorf("no account selected")
+	}
+	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
+	if err != nil {
+		return FollowAction{}, fmt.Errorf("account not found")
 	}
+	return a.followAs(acct, userID)
+}
 
-	a.db.QueryRow(`
-		SELECT COUNT(*) FROM following_snapshots
-		WHERE source_user_id = ?
-		  AND fetched_at = (SELECT MAX(fetched_at) FROM following_snapshots WHERE source_user_id = ?)
-	`, a.selectedAccountID, a.selectedAccountID).Scan(&stats.TotalCount)
+// followAs sends one follow request. An empty Status means nothing was sent.
+func (a *App) followAs(acct *Account, userID string) (FollowAction, error) {
+	action := FollowAction{AccountUserID: acct.UserID, TargetUserID: userID}
 
-	var fetchedAt string
-	a.db.QueryRow(`
-		SELECT COALESCE(MAX(fetched_at), '') FROM following_snapshots WHERE source_user_id = ?
-	`, a.selectedAccountID).Scan(&fetchedAt)
-	if fetchedAt != "" {
-		stats.LastFetchAt = fetchedAt
-		if t, err := time.Parse(time.RFC3339, fetchedAt); err == nil {
-			stats.CacheExpiresAt = t.Add(30 * 24 * time.Hour).Format(time.RFC3339)
-		}
+	client, err := a.followClient(acct)
+	if err != nil {
+		return action, err
 	}
 
-	return stats
-}
+	following, pending, err := FollowUser(a.ctx, client, acct.UserID, userID)
+	action.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	switch {
+	case err != nil:
+		action.Status, action.Error = followStatusFailed, err.Error()
+	case pending:
+		action.Status = followStatusPending
+	case following:
+		action.Status = followStatusFollowed
+	default:
+		action.Status, action.Error = followStatusFailed, "API did not confirm the follow"
+	}
 
-// --- Export ---
+	if err := SaveFollowAction(a.db, &action); err != nil {
+		log.Printf("Warning: failed to record follow action: %v", err)
+	}
+	log.Printf("[follow] @%s -> %s: %s", acct.Username, userID, action.Status)
 
-type ExportPayload struct {
-	ExportedAt         string                   `json:"exported_at"`
-	Users              []map[string]interface{} `json:"users"`
-	FollowingSnapshots []map[string]interface{} `json:"following_snapshots"`
-	FollowersSnapshots []map[string]interface{} `json:"followers_snapshots"`
-	Accounts           []map[string]interface{} `json:"accounts"`
-	ListCache          []map[string]interface{} `json:"list_cache"`
-	ListMemberCache    []map[string]interface{} `json:"list_member_cache"`
-	FetchLogs          []map[string]interface{} `json:"fetch_logs"`
+	if action.Status == followStatusFailed {
+		return action, fmt.Errorf("follow failed: %s", action.Error)
+	}
+	return action, nil
 }
 
-func (a *App) ExportData() (string, error) {
-	payload := ExportPayload{
-		ExportedAt: time.Now().UTC().Format(time.RFC3339),
-	}
-
-	tables := []struct {
-		query string
-		dest  *[]map[string]interface{}
-	}{
-		{`SELECT id, username, COALESCE(name,'') as name, COALESCE(description,'') as description,
-			COALESCE(followers_count,0) as followers_count, COALESCE(following_count,0) as following_count,
-			COALESCE(tweet_count,0) as tweet_count, COALESCE(listed_count,0) as listed_count,
-			COALESCE(verified,0) as verified, COALESCE(verified_type,'') as verified_type,
-			COALESCE(profile_image_url,'') as profile_image_url, COALESCE(location,'') as location,
-			COALESCE(created_at,'') as created_at, COALESCE(updated_at,'') as updated_at FROM users`, &payload.Users},
-		{`SELECT id, source_user_id, target_user_id, fetched_at FROM following_snapshots`, &payload.FollowingSnapshots},
-		{`SELECT id, source_user_id, target_user_id, fetched_at FROM followers_snapshots`, &payload.FollowersSnapshots},
-		{`SELECT id, user_id, username, bearer_token, is_active, created_at FROM accounts`, &payload.Accounts},
-		{`SELECT list_id, owner_user_id, name, description, member_count, private, fetched_at FROM list_cache`, &payload.ListCache},
-		{`SELECT list_id, user_id, fetched_at FROM list_member_cache`, &payload.ListMemberCache},
-		{`SELECT id, endpoint, user_id, status_code, created_at FROM fetch_logs`, &payload.FetchLogs},
-	}
-
-	for _, t := range tables {
-		rows, err := a.db.Query(t.query)
-		if err != nil {
-			return "", fmt.Errorf("querying: %w", err)
-		}
-		cols, _ := rows.Columns()
-		for rows.Next() {
-			vals := make([]interface{}, len(cols))
-			ptrs := make([]interface{}, len(cols))
-			for i := range vals {
-				ptrs[i] = &vals[i]
-			}
-			if err := rows.Scan(ptrs...); err != nil {
-				rows.Close()
-				return "", fmt.Errorf("scanning: %w", err)
-			}
-			row := make(map[string]interface{})
-			for i, col := range cols {
-				v := vals[i]
-				if b, ok := v.([]byte); ok {
-					v = string(b)
-				}
-				row[col] = v
-			}
-			*t.dest = append(*t.dest, row)
-		}
-		rows.Close()
+// followClient prefers acct's OAuth2 login (routed by NewAccountClient) and
+// falls back to OAuth1 signing.
+func (a *App) followClient(acct *Account) (*gen.ClientWithResponses, error) {
+	if _, err := GetOAuth2Token(a.db, acct.UserID); err == nil {
+		return NewAccountClient(*acct)
+	}
+	creds, err := a.userCredentials(acct)
+	if err != nil {
+		return nil, err
 	}
+	return NewUserContextClient(creds, acct.UserID)
+}
 
-	data, err := json.MarshalIndent(payload, "", "  ")
+// userCredentials returns the OAuth1 user context of acct: its stored login,
+// else the .env access token when acct is the .env user.
+func (a *App) userCredentials(acct *Account) (OAuth1Credentials, error) {
+	if token, secret, ok := GetOAuth1Token(a.db, acct.UserID); ok {
+		return OAuth1Credentials{
+			ConsumerKey:    a.config.ApiKey,
+			ConsumerSecret: a.config.ApiKeySecret,
+			AccessToken:    token,
+			AccessSecret:   secret,
+		}, nil
+	}
+
+	// The .env access token belongs to the .env user; following as any other
+	// account would fail with 403.
+	if a.config.Username != "" && !strings.EqualFold(acct.Username, a.config.Username) {
+		return OAuth1Credentials{}, fmt.Errorf("@%s has no OAuth1 login, log in from the account manager", acct.Username)
+	}
+	return OAuth1CredentialsFromConfig(a.config), nil
+}
+
+// GetFollowActions returns the latest follow actions of the selected account.
+func (a *App) GetFollowActions() []FollowAction {
+	if a.selectedAccountID == "" {
+		return nil
+	}
+	actions, err := GetFollowActions(a.db, a.selectedAccountID, 100)
 	if err != nil {
-		return "", fmt.Errorf("marshaling JSON: %w", err)
+		log.Printf("Error getting follow actions: %v", err)
+		return nil
 	}
-	return string(data), nil
+	return actions
 }
 
-// --- Helpers ---
+// --- OAuth1 login (PIN) ---
+
+// StartOAuth1Login begins a PIN login for userID and returns the URL where
+// the user authorizes the app. A login started earlier is discarded.
+func (a *App) StartOAuth1Login(userID string) (string, error) {
+	acct, err := GetAccountByUserID(a.db, userID)
+	if err != nil {
+		return "", fmt.Errorf("account not found")
+	}
+	login, err := StartOAuth1PinLogin(a.config.ApiKey, a.config.ApiKeySecret, acct.UserID)
+	if err != nil {
+		log.Printf("[auth] Error starting login for @%s: %v", acct.Username, err)
+		return "", err
+	}
+
+	a.loginMu.Lock()
+	a.pendingLogin = login
+	a.loginMu.Unlock()
+	log.Printf("[auth] OAuth1 login started for @%s", acct.Username)
+	return login.AuthorizationURL(), nil
+}
+
+// CompleteOAuth1Login exchanges the PIN for the user's access token and
+// stores it against the account the login was started for.
+func (a *App) CompleteOAuth1Login(pin string) error {
+	a.loginMu.Lock()
+	defer a.loginMu.Unlock()
+
+	login := a.pendingLogin
+	if login == nil {
+		return fmt.Errorf("no OAuth1 login in progress, start one first")
+	}
+	token, secret, err := login.Complete(a.config.ApiKey, a.config.ApiKeySecret, pin)
+	if err != nil {
+		log.Printf("[auth] Error completing login: %v", err)
+		return err
+	}
+	if err := SaveOAuth1Token(a.db, login.AccountUserID, token, secret); err != nil {
+		return fmt.Errorf("saving access token: %w", err)
+	}
+	a.pendingLogin = nil
+	log.Printf("[auth] OAuth1 login stored for account %s", login.AccountUserID)
+	return nil
+}
+
+// --- OAuth2 login (PKCE) ---
+
+// StartOAuth2Login starts a PKCE login for userID with a loopback callback
+// server and returns the URL to open; WaitOAuth2Login completes it. A login
+// still pending (abandoned or never called back) is cancelled and replaced.
+func (a *App) StartOAuth2Login(userID string) (string, error) {
+	acct, err := GetAccountByUserID(a.db, userID)
+	if err != nil {
+		return "", fmt.Errorf("account not found")
+	}
+
+	a.loginMu.Lock()
+	defer a.loginMu.Unlock()
+	if a.pendingOAuth2 != nil {
+		log.Printf("[auth] Replacing the pending OAuth2 login for account %s", a.pendingOAuth2.AccountUserID)
+		a.pendingOAuth2.Cancel()
+		a.pendingOAuth2 = nil
+	}
+	login, err := StartOAuth2PKCELogin(OAuth2ConfigFromConfig(a.config), acct.UserID)
+	if err != nil {
+		log.Printf("[auth] Error starting OAuth2 login for @%s: %v", acct.Username, err)
+		return "", err
+	}
+	a.pendingOAuth2 = login
+	log.Printf("[auth] OAuth2 login started for @%s", acct.Username)
+	return login.AuthorizationURL(), nil
+}
+
+// WaitOAuth2Login blocks until the browser returned to the callback server,
+// then stores the tokens against the account the login was started for.
+func (a *App) WaitOAuth2Login() error {
+	a.loginMu.Lock()
+	login := a.pendingOAuth2
+	a.loginMu.Unlock()
+	if login == nil {
+		return fmt.Errorf("no OAuth2 login in progress, start one first")
+	}
+
+	tok, err := login.Wait(a.ctx)
+	a.loginMu.Lock()
+	if a.pendingOAuth2 == login {
+		a.pendingOAuth2 = nil
+	}
+	a.loginMu.Unlock()
+	if err != nil {
+		log.Printf("[auth] OAuth2 login failed: %v", err)
+		return err
+	}
+
+	if err := SaveOAuth2Token(a.db, login.AccountUserID, tok); err != nil {
+		return fmt.Errorf("saving OAuth2 token: %w", err)
+	}
+	log.Printf("[auth] OAuth2 login stored for account %s", login.AccountUserID)
+	return nil
+}
+
+// --- Follow queue ---
+
+type QueueResult struct {
+	Added   int `json:"added"`
+	Skipped int `json:"skipped"`
+}
+
+// EnqueueFollows queues users to be followed by the selected account, skipping
+// anyone already in its latest following snapshot or already followed.
+func (a *App) EnqueueFollows(userIDs []string) (QueueResult, error) {
+	var result QueueResult
+	if a.selectedAccountID == "" {
+		return result, fmt.Errorf("no account selected")
+	}
+
+	following, err := a.followingSet(a.selectedAccountID)
+	if err != nil {
+		return result, err
+	}
+	for _, id := range userIDs {
+		if id == a.selectedAccountID || following[id] || HasFollowed(a.db, a.selectedAccountID, id) {
+			result.Skipped++
+			continue
+		}
+		added, err := EnqueueFollow(a.db, a.selectedAccountID, id)
+		if err != nil {
+			return result, err
+		}
+		if added {
+			result.Added++
+		} else {
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+// GetFollowQueue returns the selected account's queue with user details.
+func (a *App) GetFollowQueue() []FollowQueueItem {
+	if a.selectedAccountID == "" {
+		return nil
+	}
+	items, err := GetFollowQueue(a.db, a.selectedAccountID)
+	if err != nil {
+		log.Printf("Error getting follow queue: %v", err)
+		return nil
+	}
+
+	ids := make([]string, len(items))
+	for i, q := range items {
+		ids[i] = q.TargetUserID
+	}
+	users, err := GetUsersByIDs(a.db, ids)
+	if err != nil {
+		log.Printf("Error getting queued users: %v", err)
+		return items
+	}
+	byID := make(map[string]*FollowingUser, len(users))
+	for i := range users {
+		byID[users[i].Id] = &users[i]
+	}
+	for i := range items {
+		items[i].User = byID[items[i].TargetUserID]
+	}
+	return items
+}
+
+func (a *App) RemoveQueuedFollow(id int) error {
+	return DeleteFollowQueueItem(a.db, a.selectedAccountID, id)
+}
+
+// GetFollowSchedule returns the daily cap, today's follows and the next
+// scheduled follow of the selected account.
+func (a *App) GetFollowSchedule() FollowSchedule {
+	if a.selectedAccountID == "" {
+		return FollowSchedule{}
+	}
+	sched := GetFollowSchedule(a.db, a.selectedAccountID)
+	sched.FollowedToday = CountFollowsSince(a.db, a.selectedAccountID, startOfDay(time.Now()).UTC().Format(time.RFC3339))
+	return sched
+}
+
+func (a *App) SetFollowDailyLimit(limit int) error {
+	if a.selectedAccountID == "" {
+		return fmt.Errorf("no account selected")
+	}
+	if limit < 1 {
+		return fmt.Errorf("daily limit must be at least 1")
+	}
+	return SetFollowDailyLimit(a.db, a.selectedAccountID, limit)
+}
+
+// --- Recommendations (friends of friends) ---
+
+func (a *App) GetSeeds() []Seed {
+	if a.selectedAccountID == "" {
+		return nil
+	}
+	seeds, err := GetSeeds(a.db, a.selectedAccountID)
+	if err != nil {
+		log.Printf("Error getting seeds: %v", err)
+		return nil
+	}
+	return seeds
+}
+
+// AddSeed resolves username and adds it as a recommendation seed of the
+// selected account. Its following list is fetched by FetchSeedsNow.
+func (a *App) AddSeed(username string) error {
+	if a.selectedAccountID == "" {
+		return fmt.Errorf("no account selected")
+	}
+	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
+	if err != nil {
+		return fmt.Errorf("account not found")
+	}
+	client, err := NewAccountClient(*acct)
+	if err != nil {
+		return err
+	}
+
+	user, err := LookupUser(a.ctx, client, strings.TrimPrefix(strings.TrimSpace(username), "@"))
+	if err != nil {
+		return fmt.Errorf("could not resolve @%s: %w", username, err)
+	}
+	if err := UpsertUser(a.db, *user); err != nil {
+		log.Printf("Warning: failed to upsert user %s: %v", user.Id, err)
+	}
+	return AddSeed(a.db, a.selectedAccountID, user.Id)
+}
+
+func (a *App) RemoveSeed(userID string) error {
+	return RemoveSeed(a.db, a.selectedAccountID, userID)
+}
+
+// EstimateFetchSeeds mirrors FetchSeedsNow: the following list of every seed
+// whose cache is stale.
+func (a *App) EstimateFetchSeeds() CostEstimate {
+	est := CostEstimate{Action: "seeds", Known: true}
+	if a.selectedAccountID == "" {
+		return est
+	}
+	for _, s := range a.GetSeeds() {
+		est.add(EstimateUserFetch(a.db, endpointFollowing, a.selectedAccountID, s.UserID), IsFollowingCacheFresh(a.db, s.UserID))
+	}
+	est.Budget = CheckBudget(a.db, a.selectedAccountID, FetchEstimate{Endpoint: endpointFollowing, Items: est.Items, Cost: est.Cost, Known: est.Known})
+	return est
+}
+
+// FetchSeedsNow fetches the following list of every seed with a stale cache
+// into following_snapshots, with the seed as source. A seed whose list would
+// not fit the budget is skipped rather than truncated, since a partial list
+// skews the overlap counts; one that still hits the budget cap (its count was
+// unknown or stale) keeps its progress but saves no snapshot.
+func (a *App) FetchSeedsNow() string {
+	if a.selectedAccountID == "" {
+		return "No account selected. Add an account first."
+	}
+	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
+	if err != nil {
+		return "Account not found."
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	client, err := NewAccountClient(*acct)
+	if err != nil {
+		return fmt.Sprintf("Error for @%s: %v", acct.Username, err)
+	}
+
+	var fetched, skipped int
+	var messages []string
+	for _, s := range a.GetSeeds() {
+		if IsFollowingCacheFresh(a.db, s.UserID) {
+			skipped++
+			continue
+		}
+
+		est := EstimateUserFetch(a.db, endpointFollowing, acct.UserID, s.UserID)
+		budget := CheckBudget(a.db, acct.UserID, est)
+		if budget.Allowed && budget.MaxItems > 0 && budget.MaxItems < est.Items {
+			budget.Allowed = false
+			budget.Message = fmt.Sprintf("budget allows %d of ~%d items", budget.MaxItems, est.Items)
+		}
+		if budget.Message != "" {
+			log.Printf("[budget] seed @%s: %s", s.Username, budget.Message)
+		}
+		if !budget.Allowed {
+			messages = append(messages, fmt.Sprintf("@%s skipped: %s", s.Username, budget.Message))
+			continue
+		}
+
+		p := NewFollowingPaginator(client, s.UserID)
+		p.MaxItems = budget.MaxItems
+		job, complete, err := a.runFetchJob(a.ctx, endpointFollowing, acct.UserID, s.UserID, p)
+		if err != nil {
+			log.Printf("[fetch] seed @%s: %v", s.Username, err)
+			messages = append(messages, fmt.Sprintf("@%s: %v (progress saved)", s.Username, err))
+			continue
+		}
+		if !complete {
+			messages = append(messages, fmt.Sprintf("@%s stopped at budget cap after %d", s.Username, job.Items))
+			continue
+		}
+		if _, err := CompleteFetchJobSnapshot(a.db, job.ID, "following_snapshots", s.UserID); err != nil {
+			log.Printf("Warning: failed to save seed snapshot: %v", err)
+			continue
+		}
+		LogFetch(a.db, endpointFollowing, s.UserID, 200)
+		fetched++
+	}
+
+	msg := fmt.Sprintf("Fetched %d seeds (%d cached) at %s", fetched, skipped, time.Now().Format("15:04:05"))
+	if len(messages) > 0 {
+		msg += "; " + strings.Join(messages, "; ")
+	}
+	log.Println(msg)
+	return msg
+}
+
+// GetRecommendations ranks users followed by at least minSeeds seeds that
+// the selected account does not follow yet.
+func (a *App) GetRecommendations(minSeeds int) []Recommendation {
+	if a.selectedAccountID == "" {
+		return nil
+	}
+	recs, err := BuildRecommendations(a.db, a.selectedAccountID, max(minSeeds, 1), 200)
+	if err != nil {
+		log.Printf("Error building recommendations: %v", err)
+		return nil
+	}
+
+	users := make([]FollowingUser, len(recs))
+	for i := range recs {
+		users[i] = recs[i].FollowingUser
+	}
+	for i, u := range a.enrichWithListNames(a.selectedAccountID, users) {
+		recs[i].Lists = u.Lists
+	}
+	return recs
+}
+
+// --- Content stability ---
+
+// tweetsPerScore is how many recent original posts a content score reads.
+const tweetsPerScore = 100
+
+// EstimateScoreContent is the dry run of ScoreUserContent.
+func (a *App) EstimateScoreContent(userID string) CostEstimate {
+	est := CostEstimate{Action: "tweets", Known: true}
+	if a.selectedAccountID == "" {
+		return est
+	}
+	est.add(a.tweetsEstimate(userID), false)
+	est.Budget = CheckBudget(a.db, a.selectedAccountID, est.Targets[0])
+	return est
+}
+
+func (a *App) tweetsEstimate(userID string) FetchEstimate {
+	return FetchEstimate{
+		Endpoint: endpointUserTweets,
+		TargetID: userID,
+		Items:    tweetsPerScore,
+		Pages:    1,
+		Cost:     tweetsPerScore * GetUnitPrice(a.db, endpointUserTweets),
+		Known:    true,
+	}
+}
+
+// ScoreUserContent fetches userID's latest original posts, stores them and
+// recomputes their content stability score.
+func (a *App) ScoreUserContent(userID string) (ContentStats, error) {
+	if a.selectedAccountID == "" {
+		return ContentStats{}, fmt.Errorf("no account selected")
+	}
+	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
+	if err != nil {
+		return ContentStats{}, fmt.Errorf("account not found")
+	}
+
+	budget := CheckBudget(a.db, acct.UserID, a.tweetsEstimate(userID))
+	if !budget.Allowed {
+		return ContentStats{}, fmt.Errorf("%s", budget.Message)
+	}
+
+	client, err := NewAccountClient(*acct)
+	if err != nil {
+		return ContentStats{}, err
+	}
+	p := NewUserTweetsPaginator(client, userID)
+	p.MaxItems = tweetsPerScore
+	if budget.MaxItems > 0 && budget.MaxItems < p.MaxItems {
+		p.MaxItems = budget.MaxItems
+	}
+	tweets, err := p.All(a.ctx)
+	if err != nil {
+		return ContentStats{}, err
+	}
+	if err := SaveTweets(a.db, userID, tweets); err != nil {
+		return ContentStats{}, err
+	}
+	LogFetch(a.db, endpointUserTweets, userID, 200)
+
+	stored, err := GetTweets(a.db, userID, tweetsPerScore)
+	if err != nil {
+		return ContentStats{}, err
+	}
+	followers, _, _ := GetUserCounts(a.db, userID)
+	stats := ComputeContentStats(userID, stored, followers)
+	if err := SaveContentStats(a.db, stats); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// GetContentStats returns the last computed content score of userID, or nil.
+func (a *App) GetContentStats(userID string) *ContentStats {
+	stats, err := GetContentStats(a.db, userID)
+	if err != nil {
+		return nil
+	}
+	return stats
+}
+
+// --- Metric history ---
+
+// GetUserMetricsHistory returns every recorded change in userID's public
+// metrics, oldest first.
+func (a *App) GetUserMetricsHistory(userID string) []MetricPoint {
+	points, err := GetUserMetricsHistory(a.db, userID)
+	if err != nil {
+		log.Printf("Error getting metrics history: %v", err)
+		return nil
+	}
+	return points
+}
+
+// GetFastestGrowing ranks accounts the selected account follows by follower
+// growth over the last days.
+func (a *App) GetFastestGrowing(days int) []GrowthUser {
+	if a.selectedAccountID == "" {
+		return nil
+	}
+	since := time.Now().AddDate(0, 0, -max(days, 1))
+	users, err := FindFastestGrowing(a.db, a.selectedAccountID, since, 100)
+	if err != nil {
+		log.Printf("Error finding growing accounts: %v", err)
+		return nil
+	}
+	return users
+}
+
+// GetDormantAccounts lists accounts the selected account follows whose
+// tweet_count hasn't changed for at least days.
+func (a *App) GetDormantAccounts(days int) []DormantUser {
+	if a.selectedAccountID == "" {
+		return nil
+	}
+	users, err := FindDormant(a.db, a.selectedAccountID, max(days, 1))
+	if err != nil {
+		log.Printf("Error finding dormant accounts: %v", err)
+		return nil
+	}
+	return users
+}
+
+// GetAccountGrowth returns the daily follower/following series of an own
+// account over rangeKey ("7d", "30d", "90d", "1y" or "all").
+func (a *App) GetAccountGrowth(userID, rangeKey string) (AccountGrowth, error) {
+	if userID == "" {
+		userID = a.selectedAccountID
+	}
+	if userID == "" {
+		return AccountGrowth{}, fmt.Errorf("no account selected")
+	}
+	return BuildAccountGrowth(a.db, userID, rangeKey)
+}
+
+// --- Fetching (manual only, no scheduler) ---
+
+// FetchNow fetches following for the currently selected account (manual trigger from UI).
+func (a *App) FetchNow() string {
+	if a.selectedAccountID == "" {
+		return "No account selected. Add an account first."
+	}
+
+	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
+	if err != nil {
+		return "Account not found."
+	}
+
+	return statusMessage(a.fetchFollowingForAccount(a.ctx, *acct))
+}
+
+// FetchListsNow fetches owned lists + all members (with 30-day cache check).
+// Lists whose member fetch failed earlier are resumed even when the list cache is fresh.
+func (a *App) FetchListsNow() string {
+	if a.selectedAccountID == "" {
+		return "No account selected. Add an account first."
+	}
+	acct, err := GetAccountByUserID(a.db, a.selectedAccountID)
+	if err != nil {
+		return "Account not found."
+	}
+	return statusMessage(a.fetchListsForAccount(a.ctx, *acct))
+}
+
+func (a *App) fetchListsForAccount(ctx context.Context, acct Account) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var lists []TwitterList
+	if IsListCacheFresh(a.db, acct.UserID) {
+		lists = GetCachedLists(a.db, acct.UserID)
+	} else {
+		cached := GetCachedLists(a.db, acct.UserID)
+		budget := CheckBudget(a.db, acct.UserID, FetchEstimate{
+			Endpoint: endpointOwnedLists,
+			Items:    len(cached),
+			Pages:    1,
+			Cost:     float64(len(cached)) * GetUnitPrice(a.db, endpointOwnedLists),
+			Known:    len(cached) > 0,
+		})
+		if !budget.Allowed {
+			log.Printf("[budget] %s", budget.Message)
+			return "", errors.New(budget.Message)
+		}
+
+		lists = a.fetchAndCacheOwnedLists(ctx, acct)
+		if lists == nil {
+			return "", errors.New("error fetching lists")
+		}
+	}
+
+	// Refuse up front if the lists together don't fit, rather than spending
+	// on the first few and stopping half-way.
+	var total FetchEstimate
+	total.Endpoint = endpointListMembers
+	total.Known = true
+	var stale []TwitterList
+	for _, l := range lists {
+		if IsListMemberCacheFresh(a.db, l.Id) {
+			continue
+		}
+		stale = append(stale, l)
+		est := EstimateUserFetch(a.db, endpointListMembers, acct.UserID, l.Id)
+		total.Items += est.Items
+		total.Cost += est.Cost
+		total.Known = total.Known && est.Known
+	}
+	if len(stale) == 0 {
+		msg := "Cache fresh for lists, skipping API call"
+		log.Println(msg)
+		return msg, nil
+	}
+	budget := CheckBudget(a.db, acct.UserID, total)
+	if budget.Message != "" {
+		log.Printf("[budget] lists: %s", budget.Message)
+	}
+	if !budget.Allowed {
+		return "", errors.New(budget.Message)
+	}
+
+	// Without a count for every list the total is only a floor, so the run
+	// as a whole is capped at what the budget affords.
+	capped := !total.Known && budget.MaxItems > 0
+	left := 0
+	if capped {
+		left = budget.MaxItems
+	}
+	fetched := 0
+	var errs []error
+	for _, l := range stale {
+		if capped && left <= 0 {
+			errs = append(errs, fmt.Errorf("list %q skipped: run reached the budget cap of %d members", l.Name, budget.MaxItems))
+			continue
+		}
+		read, err := a.fetchAndCacheListMembers(ctx, acct, l.Id, left)
+		left -= read
+		if err != nil {
+			log.Printf("[fetch] list %s: %v", l.Id, err)
+			errs = append(errs, fmt.Errorf("list %q: %w", l.Name, err))
+			continue
+		}
+		fetched++
+	}
+
+	if len(errs) > 0 {
+		return "", fmt.Errorf("fetched %d of %d lists: %w", fetched, len(stale), errors.Join(errs...))
+	}
+	return fmt.Sprintf("Fetched %d lists at %s", fetched, time.Now().Format("15:04:05")), nil
+}
+
+func (a *App) fetchFollowingForAccount(ctx context.Context, acct Account) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if IsFollowingCacheFresh(a.db, acct.UserID) {
+		msg := fmt.Sprintf("Cache fresh for @%s, skipping API call", acct.Username)
+		log.Println(msg)
+		return msg, nil
+	}
+
+	budget := CheckBudget(a.db, acct.UserID, EstimateUserFetch(a.db, endpointFollowing, acct.UserID, acct.UserID))
+	if budget.Message != "" {
+		log.Printf("[budget] @%s: %s", acct.Username, budget.Message)
+	}
+	if !budget.Allowed {
+		return "", errors.New(budget.Message)
+	}
+
+	log.Printf("[fetch] Fetching for @%s (user_id=%s, token=%s...)", acct.Username, acct.UserID, acct.BearerToken[:min(8, len(acct.BearerToken))])
+	client, err := NewAccountClient(acct)
+	if err != nil {
+		log.Printf("Error for @%s: %v", acct.Username, err)
+		return "", fmt.Errorf("error for @%s: %w", acct.Username, err)
+	}
+
+	p := NewFollowingPaginator(client, acct.UserID)
+	p.MaxItems = budget.MaxItems
+	job, complete, err := a.runFetchJob(ctx, endpointFollowing, acct.UserID, acct.UserID, p)
+	if err != nil {
+		log.Printf("Fetch error for @%s: %v", acct.Username, err)
+		return "", fmt.Errorf("fetch error for @%s: %w (progress saved, Fetch Now resumes)", acct.Username, err)
+	}
+	if !complete {
+		return fmt.Sprintf("Fetched %d for @%s, stopped at budget cap; Fetch Now resumes", job.Items, acct.Username), nil
+	}
+
+	n, err := CompleteFetchJobSnapshot(a.db, job.ID, "following_snapshots", acct.UserID)
+	if err != nil {
+		log.Printf("Warning: failed to save snapshot: %v", err)
+	}
+	LogFetch(a.db, endpointFollowing, acct.UserID, 200)
+
+	msg := fmt.Sprintf("Fetched %d for @%s at %s", n, acct.Username, time.Now().Format("15:04:05"))
+	log.Println(msg)
+	return msg, nil
+}
+
+// runFetchJob streams p page by page into SQLite, resuming the persisted job
+// for endpoint/account/target if an earlier run stopped part-way. Nothing is
+// buffered in memory; the caller commits the staged snapshot atomically.
+// complete is false when the paginator stopped at a cap; the job then stays
+// pending for the next run.
+func (a *App) runFetchJob(ctx context.Context, endpoint, accountUserId, targetId string, p *Paginator[gen.User]) (*FetchJob, bool, error) {
+	job, err := GetOrCreateFetchJob(a.db, endpoint, accountUserId, targetId)
+	if err != nil {
+		return nil, false, fmt.Errorf("loading fetch job: %w", err)
+	}
+
+	// All pages stored by an earlier run, only the commit is missing.
+	if job.Pages > 0 && job.PaginationToken == "" {
+		return job, true, nil
+	}
+
+	var startToken *string
+	if job.PaginationToken != "" {
+		startToken = &job.PaginationToken
+		log.Printf("[fetch] Resuming %s for %s after %d pages (%d users)", endpoint, targetId, job.Pages, job.Items)
+	}
+
+	result, err := p.Each(ctx, startToken, func(page Page[gen.User]) error {
+		return SaveFetchJobPage(a.db, job.ID, page.Items, page.NextToken)
+	})
+	if err != nil {
+		FailFetchJob(a.db, job.ID, err)
+		return nil, false, err
+	}
+
+	job.Pages += result.Pages
+	job.Items += result.Items
+	return job, result.NextToken == nil, nil
+}
+
+// GetFetchJobs returns unfinished fetches of the selected account that the next Fetch Now will resume.
+func (a *App) GetFetchJobs() []FetchJob {
+	if a.selectedAccountID == "" {
+		return nil
+	}
+	jobs, err := GetFetchJobs(a.db, a.selectedAccountID)
+	if err != nil {
+		log.Printf("Error getting fetch jobs: %v", err)
+		return nil
+	}
+	return jobs
+}
+
+// DiscardFetchJob drops a pending job so the next fetch starts from the first page.
+func (a *App) DiscardFetchJob(id int) error {
+	return DeleteFetchJob(a.db, id)
+}
+
+// --- Data queries (scoped to selectedAccountID) ---
+
+func (a *App) GetFollowingList() []FollowingUser {
+	return a.followingList(a.selectedAccountID)
+}
+
+func (a *App) followingList(accountID string) []FollowingUser {
+	if accountID == "" {
+		return nil
+	}
+
+	rows, err := a.db.Query(`
+		SELECT u.id, u.username, COALESCE(u.name, ''), COALESCE(u.description, ''),
+			COALESCE(u.followers_count, 0), COALESCE(u.following_count, 0),
+			COALESCE(u.tweet_count, 0), COALESCE(u.listed_count, 0),
+			COALESCE(u.verified, 0), COALESCE(u.verified_type, ''),
+			COALESCE(u.profile_image_url, ''), COALESCE(u.location, ''),
+			COALESCE(u.created_at, ''), COALESCE(u.updated_at, ''),
+			COALESCE(cs.score, 0)
+		FROM users u
+		INNER JOIN following_snapshots fs ON u.id = fs.target_user_id
+		LEFT JOIN content_scores cs ON cs.user_id = u.id
+		WHERE fs.source_user_id = ?
+		  AND fs.fetched_at = (
+			  SELECT MAX(fetched_at) FROM following_snapshots
+			  WHERE source_user_id = ?
+		  )
+		ORDER BY u.followers_count DESC
+	`, accountID, accountID)
+	if err != nil {
+		log.Printf("Error querying users: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var users []FollowingUser
+	for rows.Next() {
+		var u FollowingUser
+		var verified int
+		err := rows.Scan(&u.Id, &u.Username, &u.Name, &u.Description,
+			&u.FollowersCount, &u.FollowingCount, &u.TweetCount, &u.ListedCount,
+			&verified, &u.VerifiedType, &u.ProfileImageUrl, &u.Location,
+			&u.CreatedAt, &u.UpdatedAt, &u.StabilityScore)
+		if err != nil {
+			log.Printf("Error scanning user: %v", err)
+			continue
+		}
+		u.Verified = verified == 1
+		users = append(users, u)
+	}
+	return users
+}
+
+func (a *App) GetStats() Stats {
+	return a.followingStats(a.selectedAccountID)
+}
+
+func (a *App) followingStats(accountID string) Stats {
+	var stats Stats
+	if accountID == "" {
+		return stats
+	}
+
+	a.db.QueryRow(`
+		SELECT COUNT(*) FROM following_snapshots
+		WHERE source_user_id = ?
+		  AND fetched_at = (SELECT MAX(fetched_at) FROM following_snapshots WHERE source_user_id = ?)
+	`, accountID, accountID).Scan(&stats.TotalCount)
+
+	var fetchedAt string
+	a.db.QueryRow(`
+		SELECT COALESCE(MAX(fetched_at), '') FROM following_snapshots WHERE source_user_id = ?
+	`, accountID).Scan(&fetchedAt)
+	if fetchedAt != "" {
+		stats.LastFetchAt = fetchedAt
+		if t, err := time.Parse(time.RFC3339, fetchedAt); err == nil {
+			stats.CacheExpiresAt = t.Add(30 * 24 * time.Hour).Format(time.RFC3339)
+		}
+	}
+
+	return stats
+}
+
+// --- Export ---
+
+type ExportPayload struct {
+	ExportedAt         string                   `json:"exported_at"`
+	SchemaVersion      int                      `json:"schema_version,omitempty"`
+	Scope              *ExportScope             `json:"scope,omitempty"`
+	Users              []map[string]interface{} `json:"users"`
+	FollowingSnapshots []map[string]interface{} `json:"following_snapshots"`
+	FollowersSnapshots []map[string]interface{} `json:"followers_snapshots"`
+	Accounts           []map[string]interface{} `json:"accounts"`
+	ListCache          []map[string]interface{} `json:"list_cache"`
+	ListMemberCache    []map[string]interface{} `json:"list_member_cache"`
+	FetchLogs          []map[string]interface{} `json:"fetch_logs"`
+}
+
+// ExportData returns the full export as JSON, bearer tokens redacted.
+func (a *App) ExportData() (string, error) {
+	payload, err := BuildExport(a.db, ExportOptions{})
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// ExportToFile writes a scoped export straight to disk instead of passing it
+// over the bridge. Without opts.Path a save dialog asks; cancelling returns
+// an empty summary.
+func (a *App) ExportToFile(opts ExportOptions) (ExportSummary, error) {
+	if opts.Path == "" {
+		name := "xboost-export-" + time.Now().Format("2006-01-02") + ".json"
+		if opts.Gzip {
+			name += ".gz"
+		}
+		path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Export data",
+			DefaultFilename: name,
+		})
+		if err != nil || path == "" {
+			return ExportSummary{}, err
+		}
+		opts.Path = path
+	}
+
+	payload, err := BuildExport(a.db, opts)
+	if err != nil {
+		return ExportSummary{}, err
+	}
+	size, err := WriteExport(payload, opts.Path, opts.Gzip)
+	if err != nil {
+		return ExportSummary{}, err
+	}
+	log.Printf("[export] Wrote %s (%d bytes)", opts.Path, size)
+	return ExportSummary{Path: opts.Path, Bytes: size, Gzip: opts.Gzip, Counts: exportCounts(payload)}, nil
+}
+
+// --- Import ---
+
+// ImportData loads an ExportData document, merging it into the local data
+// or, with replace, substituting the exported tables. All or nothing.
+func (a *App) ImportData(data string, replace bool) (ImportResult, error) {
+	payload, err := ParseExportPayload([]byte(data))
+	if err != nil {
+		return ImportResult{}, err
+	}
+	version, err := schemaVersion(a.db)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("reading schema version: %w", err)
+	}
+	if payload.SchemaVersion > version {
+		return ImportResult{}, fmt.Errorf("export is from schema version %d, newer than this database (%d)", payload.SchemaVersion, version)
+	}
+
+	result, err := ImportPayload(a.db, payload, replace)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("import failed, nothing was changed: %w", err)
+	}
+	for _, c := range result.Tables {
+		log.Printf("[import] %s: %d rows, %d imported, %d skipped", c.Table, c.Rows, c.Imported, c.Skipped)
+	}
+
+	// A replace may have dropped the selected account.
+	if _, err := GetAccountByUserID(a.db, a.selectedAccountID); err != nil {
+		a.selectedAccountID = ""
+		if accounts, _ := GetAllAccounts(a.db); len(accounts) > 0 {
+			a.selectedAccountID = accounts[0].UserID
+		}
+	}
+	return result, nil
+}
+
+// --- Helpers ---
+
+// statusMessage flattens a fetch result into the status line shown in the UI.
+func statusMessage(msg string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return msg
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
 
 func genUserToFollowingUser(u gen.User) FollowingUser {
 	fu := FollowingUser{
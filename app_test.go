@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchFollowingAndFollowers(t *testing.T) {
+	a, _ := newTestApp(t)
+	acct := testAccount(t, a)
+
+	if _, err := a.fetchFollowingForAccount(context.Background(), acct); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.fetchFollowersForAccount(context.Background(), acct); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(a.GetFollowingList()); got != 220 {
+		t.Errorf("following list has %d users, want 220", got)
+	}
+	if got := len(a.GetFollowersList()); got != 180 {
+		t.Errorf("followers list has %d users, want 180", got)
+	}
+	if got := a.GetFollowersStats().TotalCount; got != 180 {
+		t.Errorf("followers stats count %d, want 180", got)
+	}
+}
+
+func TestFollowersDiffBetweenSnapshots(t *testing.T) {
+	a, fake := newTestApp(t)
+	acct := testAccount(t, a)
+
+	if _, err := a.fetchFollowersForAccount(context.Background(), acct); err != nil {
+		t.Fatal(err)
+	}
+	// Age the first snapshot past the cache window so the next fetch runs.
+	const earlier = "2020-01-01T00:00:00Z"
+	if _, err := a.db.Exec(`UPDATE followers_snapshots SET fetched_at = ?`, earlier); err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 followers leave, 5 new ones arrive.
+	fake.mu.Lock()
+	followers := append([]string{}, fake.followers[fakeAccountID][10:]...)
+	for i := 290; i < 295; i++ {
+		followers = append(followers, fakeUserID(i))
+	}
+	fake.followers[fakeAccountID] = followers
+	fake.mu.Unlock()
+
+	if _, err := a.fetchFollowersForAccount(context.Background(), acct); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := a.GetFollowersDiff("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.From != earlier {
+		t.Errorf("diff from %q, want %q", diff.From, earlier)
+	}
+	if len(diff.Gained) != 5 || len(diff.Lost) != 10 {
+		t.Errorf("gained %d, lost %d; want 5 and 10", len(diff.Gained), len(diff.Lost))
+	}
+
+	if _, err := a.GetFollowersDiff("2019-01-01T00:00:00Z", diff.To); err == nil {
+		t.Error("expected an error for a timestamp without a snapshot")
+	}
+}
+
+func TestFollowersDiffWithoutSnapshotsIsEmpty(t *testing.T) {
+	a, _ := newTestApp(t)
+
+	diff, err := a.GetFollowersDiff("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Gained == nil || diff.Lost == nil {
+		t.Errorf("gained/lost = %v/%v, want empty lists rather than null", diff.Gained, diff.Lost)
+	}
+}
+
+func TestAddNewAccountAttributesLookupSpend(t *testing.T) {
+	a, _ := newTestApp(t)
+
+	if _, err := a.AddNewAccount(fakeAccountUsername, "new-token"); err != nil {
+		t.Fatal(err)
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM api_calls WHERE account_user_id = ?`, fakeAccountID); got != 1 {
+		t.Errorf("%d lookups attributed to the account, want 1", got)
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM api_calls WHERE account_user_id = ''`); got != 0 {
+		t.Errorf("%d calls left without an account", got)
+	}
+}
+
+func TestFetchListsReportsFailedMemberFetches(t *testing.T) {
+	a, fake := newTestApp(t)
+	acct := testAccount(t, a)
+	if lists := a.fetchAndCacheOwnedLists(context.Background(), acct); len(lists) != 3 {
+		t.Fatalf("got %d owned lists, want 3", len(lists))
+	}
+
+	// The first list's member fetch is refused.
+	fake.FailNext(http.StatusForbidden)
+	_, err := a.fetchListsForAccount(context.Background(), acct)
+	if err == nil || !strings.Contains(err.Error(), "fetched 2 of 3 lists") {
+		t.Fatalf("err = %v, want one of three lists reported as failed", err)
+	}
+	if got := len(a.getListMembersFromCache(fakeAccountID, "3001")); got != 40 {
+		t.Errorf("cached %d members of the second list, want 40", got)
+	}
+}
+
+func TestFetchListsCapsRunWithoutMemberCounts(t *testing.T) {
+	a, _ := newTestApp(t)
+	acct := testAccount(t, a)
+	a.fetchAndCacheOwnedLists(context.Background(), acct)
+	if _, err := a.db.Exec(`UPDATE list_cache SET member_count = NULL`); err != nil {
+		t.Fatal(err)
+	}
+	price := GetUnitPrice(a.db, endpointListMembers)
+	limit := 100 * price
+	if err := SetBudget(a.db, Budget{MonthlyLimit: limit, Truncate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.fetchListsForAccount(context.Background(), acct); err == nil {
+		t.Fatal("expected the capped run to report unfinished lists")
+	}
+	spent := GetSpendSince(a.db, fakeAccountID, startOfMonth(time.Now().UTC()).Format(time.RFC3339))
+	if spent > limit+1e-9 {
+		t.Errorf("spent $%.4f, over the $%.4f budget", spent, limit)
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM api_calls WHERE endpoint = ?`, endpointListMembers); got != 1 {
+		t.Errorf("%d list member requests, want 1 page of 100 before the cap", got)
+	}
+}
+
+// useOAuth2Token stores an OAuth2 user token for the fake account and routes
+// user-context endpoints through it for the duration of the test.
+func useOAuth2Token(t *testing.T, a *App, token string) {
+	t.Helper()
+	userTokens.Configure(a.db, OAuth2Config{})
+	t.Cleanup(func() { userTokens.Configure(nil, OAuth2Config{}) })
+	if err := SaveOAuth2Token(a.db, fakeAccountID, &OAuth2Token{AccessToken: token, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowUserRecordsEachOutcome(t *testing.T) {
+	a, _ := newTestApp(t)
+	useOAuth2Token(t, a, "user-token")
+
+	tests := []struct {
+		target  string
+		status  string
+		wantErr bool
+	}{
+		{fakeUserID(250), followStatusFollowed, false},
+		{fakeUserID(7), followStatusPending, false}, // protected
+		{"999999", followStatusFailed, true},
+	}
+	for _, tt := range tests {
+		action, err := a.FollowUser(tt.target)
+		if (err != nil) != tt.wantErr || action.Status != tt.status {
+			t.Errorf("follow %s: status %q (%v), want %q", tt.target, action.Status, err, tt.status)
+		}
+	}
+
+	actions, err := GetFollowActions(a.db, fakeAccountID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := make(map[string]string)
+	for _, action := range actions {
+		recorded[action.TargetUserID] = action.Status
+	}
+	for _, tt := range tests {
+		if recorded[tt.target] != tt.status {
+			t.Errorf("recorded %s as %q, want %q", tt.target, recorded[tt.target], tt.status)
+		}
+	}
+}
+
+func TestFollowUserUnconfirmedIsFailure(t *testing.T) {
+	a, _ := newTestApp(t)
+	useOAuth2Token(t, a, "user-token")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, http.StatusOK, map[string]any{"data": map[string]bool{"following": false, "pending_follow": false}})
+	}))
+	defer srv.Close()
+	SetAPIBaseURL(srv.URL)
+
+	action, err := a.FollowUser(fakeUserID(250))
+	if err == nil || action.Status != followStatusFailed || !strings.Contains(action.Error, "did not confirm") {
+		t.Errorf("action %+v (%v), want a failed, unconfirmed follow", action, err)
+	}
+	if !strings.Contains(err.Error(), "did not confirm") {
+		t.Errorf("err = %v, want the unconfirmed follow reported", err)
+	}
+}
+
+func TestFollowClientPrefersOAuth2OverOAuth1(t *testing.T) {
+	a, fake := newTestApp(t)
+	a.config = &Config{
+		Username: fakeAccountUsername, ApiKey: "key", ApiKeySecret: "secret",
+		AccessToken: "oauth1-token", AccessTokenSecret: "oauth1-secret",
+	}
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			auth = r.Header.Get("Authorization")
+		}
+		fake.Handler().ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	SetAPIBaseURL(srv.URL)
+
+	if _, err := a.FollowUser(fakeUserID(250)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(auth, "OAuth ") || !strings.Contains(auth, "oauth1-token") {
+		t.Errorf("without an OAuth2 login the follow sent %q, want OAuth1 signing", auth)
+	}
+
+	useOAuth2Token(t, a, "user-token")
+	if _, err := a.FollowUser(fakeUserID(251)); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer user-token" {
+		t.Errorf("with an OAuth2 login the follow sent %q, want the user token", auth)
+	}
+}
@@ -2,9 +2,8 @@ package main
 
 import (
 	"fmt"
-	"io"
-	"log"
 	"net/http"
+	"strings"
 
 	"github.com/dghubble/oauth1"
 	"github.com/dghubble/oauth1/twitter"
@@ -18,97 +17,92 @@ func GetAccessTokenFromConfig(config Config) (string, string) {
 	return requestAccessToken, requestAccessSecret
 }
 
-func FetchAccessToken(consumerKey, consumerSecret string) (string, string) {
-	//oauth1 PIN based authentication
-	callbackUrl := "oob" // out of band
-
-	loginConfig := oauth1.Config{
+// oauth1PinConfig is the PIN based (out of band) login of the app's consumer key.
+func oauth1PinConfig(consumerKey, consumerSecret string) *oauth1.Config {
+	return &oauth1.Config{
 		ConsumerKey:    consumerKey,
 		ConsumerSecret: consumerSecret,
-		CallbackURL:    callbackUrl,
+		CallbackURL:    "oob",
 		Endpoint:       twitter.AuthenticateEndpoint,
 	}
+}
 
-	// login
-	loginToken, _, err := loginConfig.RequestToken()
-	if err != nil {
-		log.Fatal(fmt.Errorf("%w", err))
-	}
-	authorizationURL, err := loginConfig.AuthorizationURL(loginToken)
-	if err != nil {
-		log.Fatal(fmt.Errorf("%w", err))
-	}
-	fmt.Printf("Open this URL in your browser:\n%s\n", authorizationURL.String())
-
-	fmt.Println("Login token")
-	fmt.Println(loginToken)
+// OAuth1Login is a started PIN login waiting for the user to authorize the
+// app and paste the PIN shown by X.
+type OAuth1Login struct {
+	AccountUserID string
+	requestToken  string
+	requestSecret string
+	authURL       string
+}
 
-	// recive PIN
-	fmt.Printf("Paste your PIN here: ")
-	var verifier string
-	_, err = fmt.Scanf("%s", &verifier)
+// StartOAuth1PinLogin obtains a request token and the URL the user opens to
+// authorize the app.
+func StartOAuth1PinLogin(consumerKey, consumerSecret, accountUserID string) (*OAuth1Login, error) {
+	if consumerKey == "" || consumerSecret == "" {
+		return nil, fmt.Errorf("API_KEY and API_KEY_SECRET must be set for OAuth1 login")
+	}
+	config := oauth1PinConfig(consumerKey, consumerSecret)
+	requestToken, requestSecret, err := config.RequestToken()
 	if err != nil {
-		log.Fatal(fmt.Errorf("%w", err))
+		return nil, fmt.Errorf("requesting OAuth1 request token: %w", err)
 	}
-	// Twitter ignores the oauth_signature on the access token request. The user
-	// to which the request (temporary) token corresponds is already known on the
-	// server. The request for a request token earlier was validated signed by
-	// the consumer. Consumer applications can avoid keeping request token state
-	// between authorization granting and callback handling.
-	accessToken, accessSecret, err := loginConfig.AccessToken(loginToken, "secret does not matter", verifier)
+	authorizationURL, err := config.AuthorizationURL(requestToken)
 	if err != nil {
-		log.Fatal(fmt.Errorf("%w", err))
+		return nil, fmt.Errorf("building authorization URL: %w", err)
 	}
-
-	return accessToken, accessSecret
+	return &OAuth1Login{
+		AccountUserID: accountUserID,
+		requestToken:  requestToken,
+		requestSecret: requestSecret,
+		authURL:       authorizationURL.String(),
+	}, nil
 }
 
-func GetAuthenticatedClient(consumerKey, consumerSecret, accessToken, accessSecret string) *http.Client {
-	authorizeRequestToken := oauth1.NewToken(accessToken, accessSecret)
-	fmt.Println("Consumer was granted an access token to act on behalf of a user.")
-	fmt.Printf("token: %s\nsecret: %s\n", authorizeRequestToken.Token, authorizeRequestToken.TokenSecret)
-
-	// oauth1 authorize request
-	requestAccessToken := authorizeRequestToken.Token
-	requestAccessSecret := authorizeRequestToken.TokenSecret
-
-	requestConfig := oauth1.NewConfig(consumerKey, consumerSecret)
-	requestToken := oauth1.NewToken(requestAccessToken, requestAccessSecret)
-
-	// httpClient will automatically authorize http.Request's
-	httpClient := requestConfig.Client(oauth1.NoContext, requestToken)
-
-	return httpClient
+func (l *OAuth1Login) AuthorizationURL() string {
+	return l.authURL
 }
 
-func AuthenticatedOAuth1Request(httpClient *http.Client, path string) {
-	resp, err := httpClient.Get(path)
-	if err != nil {
-		log.Fatal(fmt.Errorf("%w", err))
+// Complete exchanges the PIN for the user's access token and secret.
+func (l *OAuth1Login) Complete(consumerKey, consumerSecret, pin string) (accessToken, accessSecret string, err error) {
+	pin = strings.TrimSpace(pin)
+	if pin == "" {
+		return "", "", fmt.Errorf("PIN is empty")
 	}
-
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	config := oauth1PinConfig(consumerKey, consumerSecret)
+	accessToken, accessSecret, err = config.AccessToken(l.requestToken, l.requestSecret, pin)
 	if err != nil {
-		log.Fatal(fmt.Errorf("%w", err))
+		return "", "", fmt.Errorf("exchanging PIN for access token: %w", err)
 	}
-	fmt.Println(len(body))
-	fmt.Printf("Raw Response Body:\n%v\n", string(body))
+	return accessToken, accessSecret, nil
 }
 
-func Flow() {
-	config := GetConfig()
-
-	consumerKey := config.ApiKey
-	consumerSecret := config.ApiKeySecret
+// OAuth1Credentials sign requests in user context, required by write
+// endpoints such as follow.
+type OAuth1Credentials struct {
+	ConsumerKey    string
+	ConsumerSecret string
+	AccessToken    string
+	AccessSecret   string
+}
 
-	// fetch access token
-	accessToken, accessSecret := FetchAccessToken(consumerKey, consumerSecret)
+func OAuth1CredentialsFromConfig(config *Config) OAuth1Credentials {
+	accessToken, accessSecret := GetAccessTokenFromConfig(*config)
+	return OAuth1Credentials{
+		ConsumerKey:    config.ApiKey,
+		ConsumerSecret: config.ApiKeySecret,
+		AccessToken:    accessToken,
+		AccessSecret:   accessSecret,
+	}
+}
 
-	// get authenticated client
-	httpClient := GetAuthenticatedClient(consumerKey, consumerSecret, accessToken, accessSecret)
+func (c OAuth1Credentials) Complete() bool {
+	return c.ConsumerKey != "" && c.ConsumerSecret != "" && c.AccessToken != "" && c.AccessSecret != ""
+}
 
-	// make authenticated request
-	path := fmt.Sprintf("https://api.twitter.com/2/users/%s/timelines/reverse_chronological", config.UserId)
-	AuthenticatedOAuth1Request(httpClient, path)
+// NewOAuth1HTTPClient returns a client that signs every request with creds.
+func NewOAuth1HTTPClient(creds OAuth1Credentials) *http.Client {
+	requestConfig := oauth1.NewConfig(creds.ConsumerKey, creds.ConsumerSecret)
+	requestToken := oauth1.NewToken(creds.AccessToken, creds.AccessSecret)
+	return requestConfig.Client(oauth1.NoContext, requestToken)
 }
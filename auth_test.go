@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// The token exchange itself talks to api.twitter.com; these cover what runs
+// before it.
+func TestOAuth1LoginGuards(t *testing.T) {
+	a, _ := newTestApp(t)
+	a.config = &Config{}
+
+	if _, err := a.StartOAuth1Login(fakeAccountID); err == nil || !strings.Contains(err.Error(), "API_KEY") {
+		t.Errorf("start without consumer keys: err = %v, want API_KEY required", err)
+	}
+	if _, err := a.StartOAuth1Login("nobody"); err == nil {
+		t.Error("start for an unknown account succeeded")
+	}
+	if err := a.CompleteOAuth1Login("1234"); err == nil || !strings.Contains(err.Error(), "no OAuth1 login") {
+		t.Errorf("complete without a login: err = %v, want none in progress", err)
+	}
+
+	a.pendingLogin = &OAuth1Login{AccountUserID: fakeAccountID}
+	if err := a.CompleteOAuth1Login("  \n"); err == nil || !strings.Contains(err.Error(), "PIN is empty") {
+		t.Errorf("empty PIN: err = %v, want PIN is empty", err)
+	}
+	if a.pendingLogin == nil {
+		t.Error("a rejected PIN dropped the pending login")
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// X API v2 returns 100 users per page unless max_results is set.
+const defaultPageSize = 100
+
+// minPageSize is the smallest max_results endpoint accepts.
+func minPageSize(endpoint string) int {
+	if endpoint == endpointUserTweets {
+		return 5
+	}
+	return 1
+}
+
+// FetchEstimate is the expected size and cost of one paginated fetch.
+// Known is false when no cached count exists to base it on.
+type FetchEstimate struct {
+	Endpoint string  `json:"endpoint"`
+	TargetID string  `json:"target_id"`
+	Items    int     `json:"items"`
+	Pages    int     `json:"pages"`
+	Cost     float64 `json:"cost"`
+	Known    bool    `json:"known"`
+}
+
+// EstimateUserFetch estimates endpoint for targetId from cached counts
+// (users.followers_count/following_count, list_cache.member_count), minus
+// whatever a pending fetch job has already paid for.
+func EstimateUserFetch(db *sql.DB, endpoint, accountUserId, targetId string) FetchEstimate {
+	est := FetchEstimate{Endpoint: endpoint, TargetID: targetId}
+
+	switch endpoint {
+	case endpointFollowing:
+		_, following, ok := GetUserCounts(db, targetId)
+		est.Items, est.Known = following, ok
+	case endpointFollowers:
+		followers, _, ok := GetUserCounts(db, targetId)
+		est.Items, est.Known = followers, ok
+	case endpointListMembers:
+		est.Items, est.Known = GetCachedListMemberCount(db, targetId)
+	}
+
+	if job, err := GetFetchJob(db, endpoint, accountUserId, targetId); err == nil {
+		est.Items = max(est.Items-job.Items, 0)
+	}
+
+	est.Pages = max(int(math.Ceil(float64(est.Items)/defaultPageSize)), 1)
+	est.Cost = float64(est.Items) * GetUnitPrice(db, endpoint)
+	return est
+}
+
+// BudgetDecision is the outcome of checking an estimate against the budget.
+// MaxItems > 0 caps the fetch so it fits the remaining budget; set it as the
+// paginator's MaxItems, which shrinks the last page so no more is billed.
+type BudgetDecision struct {
+	Allowed   bool    `json:"allowed"`
+	MaxItems  int     `json:"max_items"`
+	Remaining float64 `json:"remaining"`
+	Limited   bool    `json:"limited"`
+	Message   string  `json:"message"`
+}
+
+// RemainingBudget returns what is left of this month's global and account
+// budgets (the lower of the two), whether the binding budget allows
+// truncation, and false when no budget is configured.
+func RemainingBudget(db *sql.DB, accountUserId string) (remaining float64, truncate bool, limited bool) {
+	since := startOfMonth(time.Now().UTC()).Format(time.RFC3339)
+	remaining = math.Inf(1)
+
+	scopes := []string{""}
+	if accountUserId != "" {
+		scopes = append(scopes, accountUserId)
+	}
+	for _, scope := range scopes {
+		b, err := GetBudget(db, scope)
+		if err != nil || b.MonthlyLimit <= 0 {
+			continue
+		}
+		left := b.MonthlyLimit - GetSpendSince(db, scope, since)
+		if left < remaining {
+			remaining, truncate, limited = left, b.Truncate, true
+		}
+	}
+
+	if !limited {
+		return 0, false, false
+	}
+	return max(remaining, 0), truncate, true
+}
+
+// CheckBudget decides whether est may run. Unknown estimates are always
+// capped to what the remaining budget can buy.
+func CheckBudget(db *sql.DB, accountUserId string, est FetchEstimate) BudgetDecision {
+	remaining, truncate, limited := RemainingBudget(db, accountUserId)
+	if !limited {
+		return BudgetDecision{Allowed: true}
+	}
+
+	decision := BudgetDecision{Allowed: true, Remaining: remaining, Limited: true}
+	unit := GetUnitPrice(db, est.Endpoint)
+	if unit <= 0 {
+		return decision
+	}
+
+	affordable := int(math.Floor(remaining/unit + 1e-9))
+	if est.Known && est.Cost <= remaining {
+		// Still capped: the cached count may be behind the live list.
+		decision.MaxItems = affordable
+		return decision
+	}
+
+	if affordable < minPageSize(est.Endpoint) {
+		decision.Allowed = false
+		decision.Message = fmt.Sprintf("Monthly budget exhausted ($%.2f left, not even one page), skipping %s", remaining, est.Endpoint)
+		return decision
+	}
+
+	if !est.Known {
+		decision.MaxItems = affordable
+		decision.Message = fmt.Sprintf("No cached count for %s, capping at %d items ($%.2f left)", est.Endpoint, affordable, remaining)
+		return decision
+	}
+	if truncate {
+		decision.MaxItems = affordable
+		decision.Message = fmt.Sprintf("Budget allows %d of ~%d items ($%.2f left), truncating", affordable, est.Items, remaining)
+		return decision
+	}
+
+	decision.Allowed = false
+	decision.Message = fmt.Sprintf("Estimated $%.2f for %d items exceeds remaining monthly budget $%.2f, fetch refused",
+		est.Cost, est.Items, remaining)
+	return decision
+}
+
+// CostEstimate is a dry run of one Fetch Now action. Items/Pages/Cost cover
+// what would actually be requested; Skipped* is what a fresh cache avoids.
+type CostEstimate struct {
+	Action       string          `json:"action"`
+	Items        int             `json:"items"`
+	Pages        int             `json:"pages"`
+	Cost         float64         `json:"cost"`
+	SkippedItems int             `json:"skipped_items"`
+	SkippedCost  float64         `json:"skipped_cost"`
+	Known        bool            `json:"known"`
+	Targets      []FetchEstimate `json:"targets"`
+	Budget       BudgetDecision  `json:"budget"`
+}
+
+// add counts est as requested, or as skipped when fresh is true.
+func (c *CostEstimate) add(est FetchEstimate, fresh bool) {
+	c.Targets = append(c.Targets, est)
+	if fresh {
+		c.SkippedItems += est.Items
+		c.SkippedCost += est.Cost
+		return
+	}
+	c.Items += est.Items
+	c.Pages += est.Pages
+	c.Cost += est.Cost
+	c.Known = c.Known && est.Known
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"go-twitter-follower/gen"
+)
+
+func TestBudgetBlocksFetchBelowOnePage(t *testing.T) {
+	a, fake := newTestApp(t)
+	price := GetUnitPrice(a.db, endpointFollowing)
+	if err := SetBudget(a.db, Budget{MonthlyLimit: price / 2, Truncate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := a.fetchFollowingForAccount(context.Background(), testAccount(t, a))
+	if err == nil || !strings.Contains(err.Error(), "not even one page") {
+		t.Fatalf("err = %v, want the fetch refused", err)
+	}
+	if got := fake.Requests(); got != 0 {
+		t.Errorf("fake saw %d requests, want none", got)
+	}
+}
+
+func TestBudgetTruncatesFetchToAffordableItems(t *testing.T) {
+	a, fake := newTestApp(t)
+	price := GetUnitPrice(a.db, endpointFollowing)
+	limit := 150 * price
+	if err := SetBudget(a.db, Budget{MonthlyLimit: limit, Truncate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := a.fetchFollowingForAccount(context.Background(), testAccount(t, a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg, "budget cap") {
+		t.Errorf("message %q does not mention the budget cap", msg)
+	}
+	if got := fake.Requests(); got != 2 {
+		t.Errorf("fake saw %d requests, want 2", got)
+	}
+	spent := GetSpendSince(a.db, fakeAccountID, startOfMonth(time.Now().UTC()).Format(time.RFC3339))
+	if spent > limit+1e-9 {
+		t.Errorf("spent $%.4f, over the $%.4f budget", spent, limit)
+	}
+
+	// The budget is used up: the pending job is not resumed.
+	if _, err := a.fetchFollowingForAccount(context.Background(), testAccount(t, a)); err == nil {
+		t.Error("expected the exhausted budget to refuse the resume")
+	}
+	job, err := GetFetchJob(a.db, endpointFollowing, fakeAccountID, fakeAccountID)
+	if err != nil || job.Items != 150 {
+		t.Errorf("pending job = %+v (%v), want 150 items staged", job, err)
+	}
+}
+
+func TestBudgetRefusesTweetsBelowMinimumPage(t *testing.T) {
+	a, fake := newTestApp(t)
+	price := GetUnitPrice(a.db, endpointUserTweets)
+	if err := SetBudget(a.db, Budget{MonthlyLimit: 4 * price, Truncate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.ScoreUserContent(fakeUserID(0)); err == nil {
+		t.Fatal("expected the content score to be refused")
+	}
+	if got := fake.Requests(); got != 0 {
+		t.Errorf("fake saw %d requests, want none", got)
+	}
+}
+
+func TestBudgetCapsFetchThatFitsTheEstimate(t *testing.T) {
+	a, _ := newTestApp(t)
+	price := GetUnitPrice(a.db, endpointFollowing)
+	if err := SetBudget(a.db, Budget{MonthlyLimit: 300 * price}); err != nil {
+		t.Fatal(err)
+	}
+
+	decision := CheckBudget(a.db, fakeAccountID, FetchEstimate{Endpoint: endpointFollowing, Items: 100, Cost: 100 * price, Known: true})
+	if !decision.Allowed || decision.MaxItems != 300 {
+		t.Errorf("decision = %+v, want allowed and capped at 300 items", decision)
+	}
+}
+
+func TestEstimateUserFetch(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.Exec(`INSERT INTO users (id, username, followers_count, following_count) VALUES ('7', 'u7', 180, 250)`); err != nil {
+		t.Fatal(err)
+	}
+	job, err := GetOrCreateFetchJob(db, endpointFollowers, "1", "staged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO users (id, username, followers_count) VALUES ('staged', 'staged', 120)`); err != nil {
+		t.Fatal(err)
+	}
+	var page []gen.User
+	for i := 0; i < 40; i++ {
+		page = append(page, gen.User{Id: fmt.Sprintf("s%d", i)})
+	}
+	if err := SaveFetchJobPage(db, job.ID, page, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		endpoint, target string
+		items, pages     int
+		known            bool
+	}{
+		{"following count", endpointFollowing, "7", 250, 3, true},
+		{"followers count", endpointFollowers, "7", 180, 2, true},
+		{"unknown user", endpointFollowing, "nobody", 0, 1, false},
+		{"unknown list", endpointListMembers, "list", 0, 1, false},
+		{"pending job already paid for", endpointFollowers, "staged", 80, 1, true},
+	}
+	for _, tt := range tests {
+		est := EstimateUserFetch(db, tt.endpoint, "1", tt.target)
+		cost := float64(tt.items) * GetUnitPrice(db, tt.endpoint)
+		if est.Items != tt.items || est.Pages != tt.pages || est.Known != tt.known || math.Abs(est.Cost-cost) > 1e-9 {
+			t.Errorf("%s: %+v, want %d items in %d pages for $%.4f, known %v", tt.name, est, tt.items, tt.pages, cost, tt.known)
+		}
+	}
+}
+
+func TestCostEstimateAdd(t *testing.T) {
+	est := CostEstimate{Known: true}
+	est.add(FetchEstimate{Items: 250, Pages: 3, Cost: 2.5, Known: true}, false)
+	est.add(FetchEstimate{Items: 100, Pages: 1, Cost: 1, Known: true}, true)
+	if est.Items != 250 || est.Pages != 3 || est.Cost != 2.5 || est.SkippedItems != 100 || est.SkippedCost != 1 || !est.Known {
+		t.Errorf("after a known and a fresh target: %+v", est)
+	}
+	// A fresh unknown target is skipped and leaves the total known.
+	est.add(FetchEstimate{Pages: 1}, true)
+	if !est.Known {
+		t.Error("a skipped unknown target made the estimate unknown")
+	}
+	est.add(FetchEstimate{Pages: 1}, false)
+	if est.Known || est.Pages != 4 || len(est.Targets) != 4 {
+		t.Errorf("after an unknown target: %+v, want unknown with 4 pages and 4 targets", est)
+	}
+}
+
+func TestCheckBudgetDecisions(t *testing.T) {
+	db := newTestDB(t)
+	price := GetUnitPrice(db, endpointFollowing)
+	known := func(items int) FetchEstimate {
+		return FetchEstimate{Endpoint: endpointFollowing, Items: items, Cost: float64(items) * price, Known: true}
+	}
+	unknown := FetchEstimate{Endpoint: endpointFollowing}
+
+	if d := CheckBudget(db, "1", known(1000)); !d.Allowed || d.Limited || d.MaxItems != 0 {
+		t.Errorf("no budget: %+v, want allowed and uncapped", d)
+	}
+
+	tests := []struct {
+		name     string
+		budget   Budget
+		est      FetchEstimate
+		allowed  bool
+		maxItems int
+	}{
+		{"fits", Budget{MonthlyLimit: 200 * price}, known(100), true, 200},
+		{"unknown count", Budget{MonthlyLimit: 200 * price}, unknown, true, 200},
+		{"over, truncating", Budget{MonthlyLimit: 200 * price, Truncate: true}, known(500), true, 200},
+		{"over, refusing", Budget{MonthlyLimit: 200 * price}, known(500), false, 0},
+		{"below one item", Budget{MonthlyLimit: price / 2, Truncate: true}, unknown, false, 0},
+	}
+	for _, tt := range tests {
+		if err := SetBudget(db, tt.budget); err != nil {
+			t.Fatal(err)
+		}
+		d := CheckBudget(db, "1", tt.est)
+		if d.Allowed != tt.allowed || d.MaxItems != tt.maxItems || !d.Limited {
+			t.Errorf("%s: %+v, want allowed %v capped at %d", tt.name, d, tt.allowed, tt.maxItems)
+		}
+		if !d.Allowed && d.Message == "" {
+			t.Errorf("%s: refused without a message", tt.name)
+		}
+	}
+}
+
+func TestEstimateFetchFollowing(t *testing.T) {
+	a, _ := newTestApp(t)
+	if est := a.EstimateFetchFollowing(); est.Known || est.Action != "following" || len(est.Targets) != 1 {
+		t.Errorf("without a cached count: %+v, want an unknown following estimate", est)
+	}
+
+	if _, err := a.fetchFollowingForAccount(context.Background(), testAccount(t, a)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.db.Exec(`INSERT INTO users (id, username, following_count) VALUES (?, ?, 220)`, fakeAccountID, fakeAccountUsername); err != nil {
+		t.Fatal(err)
+	}
+	est := a.EstimateFetchFollowing()
+	if !est.Known || est.Items != 0 || est.SkippedItems != 220 {
+		t.Errorf("with a fresh cache: %+v, want 220 items skipped", est)
+	}
+	if lists := a.EstimateFetchLists(); lists.Known {
+		t.Errorf("lists without a cache: %+v, want unknown", lists)
+	}
+}
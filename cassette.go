@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-twitter-follower/gen"
+)
+
+const (
+	cassetteRecord = "record"
+	cassetteReplay = "replay"
+
+	redactedValue = "REDACTED"
+)
+
+// cassetteRedactedHeaders never reach a cassette file.
+var cassetteRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// CassetteEntry is one recorded request/response pair, a line of the JSONL file.
+type CassetteEntry struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestHeader  http.Header `json:"request_header"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"response_header"`
+	ResponseBody   string      `json:"response_body"`
+	RecordedAt     string      `json:"recorded_at"`
+}
+
+// Cassette records X API traffic to a JSONL file or replays it from one.
+// Replay matches method, path, query and body (not the host, so a cassette
+// recorded against api.twitter.com also replays against X_API_BASE_URL) and
+// serves repeated requests in recorded order, repeating the last one.
+type Cassette struct {
+	mu      sync.Mutex
+	path    string
+	mode    string
+	entries map[string][]CassetteEntry
+	served  map[string]int
+}
+
+// apiCassette is nil unless X_CASSETTE is set.
+var apiCassette *Cassette
+
+// OpenCassette opens path for mode "record" (appending) or "replay".
+func OpenCassette(path, mode string) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, served: make(map[string]int)}
+	switch mode {
+	case cassetteRecord:
+		return c, nil
+	case cassetteReplay:
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("cassette mode must be %s or %s, got %q", cassetteRecord, cassetteReplay, mode)
+}
+
+// ConfigureCassette sets apiCassette from X_CASSETTE / X_CASSETTE_MODE
+// (default replay); an empty path disables it.
+func ConfigureCassette(path, mode string) error {
+	if path == "" {
+		apiCassette = nil
+		return nil
+	}
+	if mode == "" {
+		mode = cassetteReplay
+	}
+	c, err := OpenCassette(path, mode)
+	if err != nil {
+		return err
+	}
+	apiCassette = c
+	log.Printf("[cassette] %s mode, %s", mode, path)
+	return nil
+}
+
+func (c *Cassette) load() error {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return fmt.Errorf("opening cassette: %w", err)
+	}
+	defer f.Close()
+
+	c.entries = make(map[string][]CassetteEntry)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e CassetteEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("cassette %s line %d: %w", c.path, line, err)
+		}
+		key, err := cassetteKey(e.Method, e.URL, e.RequestBody)
+		if err != nil {
+			return fmt.Errorf("cassette %s line %d: %w", c.path, line, err)
+		}
+		c.entries[key] = append(c.entries[key], e)
+	}
+	return scanner.Err()
+}
+
+// cassetteKey identifies a request independent of host and query order.
+func cassetteKey(method, rawURL, body string) (string, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	return method + " " + req.URL.Path + "?" + req.URL.Query().Encode() + " " + body, nil
+}
+
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range cassetteRedactedHeaders {
+		if h.Get(name) != "" {
+			h.Set(name, redactedValue)
+		}
+	}
+	return h
+}
+
+func (c *Cassette) append(e CassetteEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func (c *Cassette) replay(req *http.Request, body string) (*http.Response, error) {
+	key, err := cassetteKey(req.Method, req.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	entries := c.entries[key]
+	if len(entries) == 0 {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("cassette %s has no response for %s %s", c.path, req.Method, req.URL.RequestURI())
+	}
+	e := entries[min(c.served[key], len(entries)-1)]
+	c.served[key]++
+	c.mu.Unlock()
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.ResponseHeader.Clone(),
+		Body:          io.NopCloser(strings.NewReader(e.ResponseBody)),
+		ContentLength: int64(len(e.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// cassetteDoer records or replays through c; it sits between the rate
+// limiter and the ledger, so replayed calls are not charged.
+type cassetteDoer struct {
+	next     gen.HttpRequestDoer
+	cassette *Cassette
+}
+
+// withCassette wraps next with apiCassette when one is configured.
+func withCassette(next gen.HttpRequestDoer) gen.HttpRequestDoer {
+	if apiCassette == nil {
+		return next
+	}
+	return &cassetteDoer{next: next, cassette: apiCassette}
+}
+
+func (d *cassetteDoer) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	if d.cassette.mode == cassetteReplay {
+		return d.cassette.replay(req, string(reqBody))
+	}
+
+	res, err := d.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	err = d.cassette.append(CassetteEntry{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeader:  redactHeader(req.Header),
+		RequestBody:    string(reqBody),
+		Status:         res.StatusCode,
+		ResponseHeader: redactHeader(res.Header),
+		ResponseBody:   string(resBody),
+		RecordedAt:     time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("[cassette] Error recording %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return res, nil
+}
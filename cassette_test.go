@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordsRedactedAndReplaysOffline(t *testing.T) {
+	a, fake := newTestApp(t)
+	acct := testAccount(t, a)
+	path := filepath.Join(t.TempDir(), "following.jsonl")
+	t.Cleanup(func() { ConfigureCassette("", "") })
+
+	if err := ConfigureCassette(path, cassetteRecord); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewAccountClient(acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, _, err := GetFollowing(context.Background(), client, fakeAccountID, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), acct.BearerToken) {
+		t.Error("cassette contains the bearer token")
+	}
+	if !strings.Contains(string(data), redactedValue) {
+		t.Error("cassette has no redacted Authorization header")
+	}
+
+	if err := ConfigureCassette(path, cassetteReplay); err != nil {
+		t.Fatal(err)
+	}
+	client, err = NewAccountClient(acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := fake.Requests()
+	replayed, _, err := GetFollowing(context.Background(), client, fakeAccountID, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*replayed) != len(*recorded) || (*replayed)[0].Id != (*recorded)[0].Id {
+		t.Errorf("replayed %d users, recorded %d", len(*replayed), len(*recorded))
+	}
+	if got := fake.Requests(); got != before {
+		t.Errorf("replay reached the fake API (%d requests)", got-before)
+	}
+
+	// Anything not on the cassette fails instead of going to the network.
+	if _, _, err := GetFollowers(context.Background(), client, fakeAccountID, nil, 0); err == nil {
+		t.Error("expected an error for a request missing from the cassette")
+	}
+}
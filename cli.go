@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Headless mode: `xboost <command> [args]` runs one App operation without
+// Wails and prints a single JSON object to stdout. Logs go to stderr.
+//
+//	accounts list
+//	accounts add <username> [-token BEARER]
+//	accounts remove <username|user_id>
+//	fetch following|followers|lists [-account NAME]
+//	diff following|followers [-account NAME] [-from TS] [-to TS]
+//	export [-out FILE] [-gzip] [-account NAME] [-from DATE] [-to DATE] [-tables a,b] [-credentials redact|exclude|include]
+//	import <FILE|-> [-replace]
+//	auth [-account NAME] [-oauth2]
+//	serve [-addr HOST:PORT] [-allow-remote]
+//	fakeapi [-addr HOST:PORT] [-rate-limit N]
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+type cliCommand func(a *App, args []string) (any, error)
+
+var cliCommands = map[string]cliCommand{
+	"accounts": cliAccounts,
+	"fetch":    cliFetch,
+	"diff":     cliDiff,
+	"export":   cliExport,
+	"import":   cliImport,
+	"auth":     cliAuth,
+	"serve":    cliServe,
+	"fakeapi":  cliFakeAPI,
+}
+
+// cliOutput is the JSON envelope of every command.
+type cliOutput struct {
+	OK      bool   `json:"ok"`
+	Command string `json:"command"`
+	Result  any    `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// usageError marks bad arguments, reported with exit code 2.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+const cliUsage = "usage: xboost accounts|fetch|diff|export|import|auth|serve|fakeapi [args]"
+
+// isCLICommand reports whether the process was started as a CLI command; any
+// other flag (e.g. one a launcher passes) starts the UI.
+func isCLICommand(name string) bool {
+	return !strings.HasPrefix(name, "-") || name == "-h" || name == "--help"
+}
+
+func unknownCommand(name string) error {
+	return usagef("unknown command %q, %s", name, cliUsage)
+}
+
+// runCLI runs one command and returns the process exit code.
+func runCLI(args []string) int {
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return exitOK
+	}
+	if _, ok := cliCommands[args[0]]; !ok {
+		return writeCLIOutput(os.Stdout, args, nil, unknownCommand(args[0]))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a := NewApp()
+	defer a.shutdown(ctx)
+	// fakeapi is a standalone test server: no database, accounts or lookups.
+	if args[0] == "fakeapi" {
+		a.ctx = ctx
+	} else if err := a.initCore(ctx); err != nil {
+		return writeCLIOutput(os.Stdout, args, nil, err)
+	}
+	return a.runCommand(os.Stdout, args)
+}
+
+// runCommand runs one command on an initialized App, writes its envelope to
+// w and returns the exit code.
+func (a *App) runCommand(w io.Writer, args []string) int {
+	cmd, ok := cliCommands[args[0]]
+	if !ok {
+		return writeCLIOutput(w, args, nil, unknownCommand(args[0]))
+	}
+	result, err := cmd(a, args[1:])
+	return writeCLIOutput(w, args, result, err)
+}
+
+// writeCLIOutput writes the envelope for the command in args and returns its
+// exit code.
+func writeCLIOutput(w io.Writer, args []string, result any, err error) int {
+	out := cliOutput{Command: args[0]}
+	if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		out.Command += " " + args[1]
+	}
+	code := exitOK
+	if err != nil {
+		out.Error = err.Error()
+		code = exitFailure
+		var ue usageError
+		if errors.As(err, &ue) {
+			code = exitUsage
+		}
+	} else {
+		out.OK, out.Result = true, result
+	}
+	writeJSON(w, out)
+	return code
+}
+
+func writeJSON(w io.Writer, v any) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// parseFlags parses args with fs, allowing flags after positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, usagef("%s: %v", fs.Name(), err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// useAccount selects the account named by username or user id; empty keeps
+// the default (.env account, else the first one).
+func (a *App) useAccount(name string) (*Account, error) {
+	if name == "" {
+		if a.selectedAccountID == "" {
+			return nil, errors.New("no account configured, run `accounts add` first")
+		}
+		return GetAccountByUserID(a.db, a.selectedAccountID)
+	}
+
+	acct, err := findAccount(a.db, name)
+	if err != nil {
+		return nil, err
+	}
+	a.selectedAccountID = acct.UserID
+	return acct, nil
+}
+
+// findAccount looks an account up by username (with or without @) or user id.
+func findAccount(db *sql.DB, name string) (*Account, error) {
+	name = strings.TrimPrefix(name, "@")
+	accounts, err := GetAllAccounts(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, acct := range accounts {
+		if acct.UserID == name || strings.EqualFold(acct.Username, name) {
+			return &acct, nil
+		}
+	}
+	return nil, fmt.Errorf("account %q not found", name)
+}
+
+func cliAccounts(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("accounts", flag.ContinueOnError)
+	token := fs.String("token", "", "bearer token (default BEARER_TOKEN)")
+	pos, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(pos) == 0 {
+		return nil, usagef("accounts: expected list, add or remove")
+	}
+
+	switch pos[0] {
+	case "list":
+		accounts := a.GetAccounts()
+		if accounts == nil {
+			accounts = []Account{}
+		}
+		return accounts, nil
+	case "add":
+		if len(pos) != 2 {
+			return nil, usagef("accounts add: expected <username>")
+		}
+		if *token == "" {
+			*token = a.config.BearerToken
+		}
+		if *token == "" {
+			return nil, usagef("accounts add: -token or BEARER_TOKEN required")
+		}
+		userId, err := a.AddNewAccount(strings.TrimPrefix(pos[1], "@"), *token)
+		if err != nil {
+			return nil, err
+		}
+		return GetAccountByUserID(a.db, userId)
+	case "remove":
+		if len(pos) != 2 {
+			return nil, usagef("accounts remove: expected <username|user_id>")
+		}
+		acct, err := a.useAccount(pos[1])
+		if err != nil {
+			return nil, err
+		}
+		if err := a.RemoveAccountByID(acct.UserID); err != nil {
+			return nil, err
+		}
+		return acct, nil
+	}
+	return nil, usagef("accounts: unknown subcommand %q", pos[0])
+}
+
+// fetchResult reports one fetch. Message is the same status line the UI shows.
+type fetchResult struct {
+	Account string `json:"account"`
+	Message string `json:"message"`
+}
+
+func cliFetch(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	account := fs.String("account", "", "account username or user id")
+	pos, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(pos) != 1 || (pos[0] != "following" && pos[0] != "followers" && pos[0] != "lists") {
+		return nil, usagef("fetch: expected following, followers or lists")
+	}
+
+	acct, err := a.useAccount(*account)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg string
+	switch pos[0] {
+	case "following":
+		msg, err = a.fetchFollowingForAccount(a.ctx, *acct)
+	case "followers":
+		msg, err = a.fetchFollowersForAccount(a.ctx, *acct)
+	case "lists":
+		msg, err = a.fetchListsForAccount(a.ctx, *acct)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fetchResult{Account: acct.Username, Message: msg}, nil
+}
+
+func cliDiff(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	account := fs.String("account", "", "account username or user id")
+	from := fs.String("from", "", "older snapshot fetched_at (default previous)")
+	to := fs.String("to", "", "newer snapshot fetched_at (default latest)")
+	pos, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(pos) != 1 || (pos[0] != "following" && pos[0] != "followers") {
+		return nil, usagef("diff: expected following or followers")
+	}
+
+	if _, err := a.useAccount(*account); err != nil {
+		return nil, err
+	}
+	return a.snapshotDiff(pos[0]+"_snapshots", *from, *to)
+}
+
+// cliExport prints the export or, with -out, writes it (gzipped with -gzip
+// or a .gz name) and prints an ExportSummary. Tokens are redacted by default.
+func cliExport(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("out", "", "write the export to this file instead of stdout")
+	gz := fs.Bool("gzip", false, "gzip the -out file (default for .gz names)")
+	account := fs.String("account", "", "only this account (username or user id)")
+	from := fs.String("from", "", "snapshots fetched at or after (YYYY-MM-DD or RFC3339)")
+	to := fs.String("to", "", "snapshots fetched at or before (YYYY-MM-DD or RFC3339)")
+	tables := fs.String("tables", "", "comma-separated tables (default all)")
+	credentials := fs.String("credentials", credentialsRedact, "bearer tokens: redact, exclude or include")
+	if _, err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	opts := ExportOptions{From: *from, To: *to, Credentials: *credentials, Path: *out}
+	opts.Gzip = *gz || strings.HasSuffix(*out, ".gz")
+	if opts.Gzip && *out == "" {
+		return nil, usagef("export: -gzip needs -out")
+	}
+	if *tables != "" {
+		opts.Tables = strings.Split(*tables, ",")
+	}
+	if *account != "" {
+		acct, err := findAccount(a.db, *account)
+		if err != nil {
+			return nil, err
+		}
+		opts.AccountUserID = acct.UserID
+	}
+
+	if *out != "" {
+		return a.ExportToFile(opts)
+	}
+	return BuildExport(a.db, opts)
+}
+
+// cliImport loads an export file ("-" reads stdin) and reports per-table counts.
+func cliImport(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	replace := fs.Bool("replace", false, "replace the exported tables instead of merging")
+	pos, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(pos) != 1 {
+		return nil, usagef("import: expected <file> or -")
+	}
+
+	var data []byte
+	if pos[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(pos[0])
+	}
+	if err == nil && bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		data, err = gunzip(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading import: %w", err)
+	}
+	return a.ImportData(string(data), *replace)
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// authResult reports a stored login; the tokens themselves are never printed.
+type authResult struct {
+	Account string `json:"account"`
+	Stored  bool   `json:"stored"`
+}
+
+// cliAuth runs the OAuth1 PIN (default) or OAuth2 PKCE login for an
+// account; the prompt goes to stderr.
+func cliAuth(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	account := fs.String("account", "", "account username or user id")
+	useOAuth2 := fs.Bool("oauth2", false, "use OAuth2 PKCE with a loopback callback")
+	if _, err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	acct, err := a.useAccount(*account)
+	if err != nil {
+		return nil, err
+	}
+
+	if *useOAuth2 {
+		authURL, err := a.StartOAuth2Login(acct.UserID)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Open this URL in your browser:\n%s\nWaiting for the callback...\n", authURL)
+		if err := a.WaitOAuth2Login(); err != nil {
+			return nil, err
+		}
+		return authResult{Account: acct.Username, Stored: true}, nil
+	}
+
+	authURL, err := a.StartOAuth1Login(acct.UserID)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Open this URL in your browser:\n%s\nPaste your PIN here: ", authURL)
+	pin, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && pin == "" {
+		return nil, fmt.Errorf("reading PIN: %w", err)
+	}
+	if err := a.CompleteOAuth1Login(pin); err != nil {
+		return nil, err
+	}
+	return authResult{Account: acct.Username, Stored: true}, nil
+}
+
+// cliServe runs the REST API (server.go) until interrupted.
+func cliServe(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", defaultServeAddr, "listen address")
+	allowRemote := fs.Bool("allow-remote", false, "allow listening on a non-loopback address")
+	if _, err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return nil, usagef("serve: invalid -addr %q: %v", *addr, err)
+	}
+	if !isLoopbackHost(host) && !*allowRemote {
+		return nil, usagef("serve: %q is not a loopback address, pass -allow-remote to expose the API", *addr)
+	}
+	srv, err := newAPIServer(a)
+	if err != nil {
+		return nil, err
+	}
+	if err := srv.Serve(a.ctx, *addr); err != nil {
+		return nil, err
+	}
+	return map[string]string{"addr": *addr}, nil
+}
+
+const defaultFakeAPIAddr = "127.0.0.1:8788"
+
+// cliFakeAPI serves the fake X API (fakeapi.go) until interrupted; run the
+// app with X_API_BASE_URL=http://<addr> to use it.
+func cliFakeAPI(a *App, args []string) (any, error) {
+	fs := flag.NewFlagSet("fakeapi", flag.ContinueOnError)
+	addr := fs.String("addr", defaultFakeAPIAddr, "listen address")
+	rateLimit := fs.Int("rate-limit", 0, "requests per endpoint every 2s before answering 429 (0 = never)")
+	if _, err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	fake := NewFakeXAPI()
+	fake.RateLimit = *rateLimit
+	srv := &http.Server{Addr: *addr, Handler: fake.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("[fakeapi] Listening on http://%s (account @%s)", *addr, fakeAccountUsername)
+
+	select {
+	case err := <-errc:
+		return nil, err
+	case <-a.ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return nil, err
+	}
+	return map[string]any{"addr": *addr, "requests": fake.Requests()}, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRunCommandEnvelopeAndExitCodes(t *testing.T) {
+	a, _ := newTestApp(t)
+
+	tests := []struct {
+		args    []string
+		command string
+		want    int
+	}{
+		{[]string{"accounts", "list"}, "accounts list", exitOK},
+		{[]string{"fetch", "following"}, "fetch following", exitOK},
+		{[]string{"diff", "following", "-from", "2000-01-01T00:00:00Z", "-to", "2000-01-02T00:00:00Z"}, "diff following", exitFailure},
+		{[]string{"accounts", "remove", "nobody_here"}, "accounts remove", exitFailure},
+		{[]string{"accounts"}, "accounts", exitUsage},
+		{[]string{"fetch", "everything"}, "fetch everything", exitUsage},
+		{[]string{"export", "-gzip"}, "export", exitUsage},
+		{[]string{"diff", "followers", "-bogus"}, "diff followers", exitUsage},
+		{[]string{"fetc", "following"}, "fetc following", exitUsage},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		code := a.runCommand(&stdout, tt.args)
+		if code != tt.want {
+			t.Errorf("%v: exit code %d, want %d", tt.args, code, tt.want)
+		}
+
+		dec := json.NewDecoder(&stdout)
+		var out cliOutput
+		if err := dec.Decode(&out); err != nil {
+			t.Errorf("%v: stdout is not JSON: %v", tt.args, err)
+			continue
+		}
+		if err := dec.Decode(&json.RawMessage{}); !errors.Is(err, io.EOF) {
+			t.Errorf("%v: stdout holds more than one JSON value (%v)", tt.args, err)
+		}
+		if out.Command != tt.command || out.OK != (tt.want == exitOK) || (out.Error == "") != out.OK {
+			t.Errorf("%v: envelope %+v, want command %q and ok %v", tt.args, out, tt.command, tt.want == exitOK)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// minScoredTweets is the fewest posts a score is computed from; below that
+// cadence and variance are noise.
+const minScoredTweets = 5
+
+// ContentStats summarises a user's recent original posts. CadenceCV and
+// EngagementCV are coefficients of variation (stddev / mean): lower is
+// steadier. Score is 0-100, higher is more stable content.
+type ContentStats struct {
+	UserID         string  `json:"user_id"`
+	Tweets         int     `json:"tweets"`
+	PostsPerWeek   float64 `json:"posts_per_week"`
+	CadenceCV      float64 `json:"cadence_cv"`
+	EngagementRate float64 `json:"engagement_rate"`
+	EngagementCV   float64 `json:"engagement_cv"`
+	Score          float64 `json:"score"`
+	ComputedAt     string  `json:"computed_at"`
+}
+
+// ComputeContentStats scores tweets (any order) of a user with the given
+// follower count. The score weighs activity (up to one post a day counts),
+// regular posting gaps and consistent engagement per post.
+func ComputeContentStats(userId string, tweets []StoredTweet, followers int) ContentStats {
+	stats := ContentStats{
+		UserID:     userId,
+		Tweets:     len(tweets),
+		ComputedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if len(tweets) < minScoredTweets {
+		return stats
+	}
+
+	engagement := make([]float64, len(tweets))
+	sorted := make([]time.Time, len(tweets))
+	for i, t := range tweets {
+		engagement[i] = float64(t.Engagements())
+		sorted[i] = t.CreatedAt
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	gaps := make([]float64, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		gaps = append(gaps, sorted[i].Sub(sorted[i-1]).Hours())
+	}
+
+	weeks := math.Max(sorted[len(sorted)-1].Sub(sorted[0]).Hours()/(24*7), 1.0/7)
+	stats.PostsPerWeek = round3(float64(len(tweets)) / weeks)
+	stats.CadenceCV = round3(coefficientOfVariation(gaps))
+	stats.EngagementCV = round3(coefficientOfVariation(engagement))
+	if followers > 0 {
+		stats.EngagementRate = round3(mean(engagement) / float64(followers))
+	}
+
+	activity := math.Min(stats.PostsPerWeek/7, 1)
+	cadence := 1 / (1 + stats.CadenceCV)
+	consistency := 1 / (1 + stats.EngagementCV)
+	stats.Score = math.Round(100*(0.3*activity+0.35*cadence+0.35*consistency)*10) / 10
+	return stats
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func coefficientOfVariation(xs []float64) float64 {
+	m := mean(xs)
+	if m == 0 {
+		return 0
+	}
+	var sq float64
+	for _, x := range xs {
+		sq += (x - m) * (x - m)
+	}
+	return math.Sqrt(sq/float64(len(xs))) / m
+}
+
+func round3(x float64) float64 {
+	return math.Round(x*1000) / 1000
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// dailyTweets returns one post a day, newest first, with the given likes.
+func dailyTweets(likes ...int) []StoredTweet {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	tweets := make([]StoredTweet, len(likes))
+	for i, n := range likes {
+		tweets[len(likes)-1-i] = StoredTweet{CreatedAt: start.AddDate(0, 0, i), LikeCount: n}
+	}
+	return tweets
+}
+
+func TestComputeContentStats(t *testing.T) {
+	steady := ComputeContentStats("u", dailyTweets(10, 10, 10, 10, 10, 10, 10), 100)
+	if steady.CadenceCV != 0 || steady.EngagementCV != 0 || steady.EngagementRate != 0.1 {
+		t.Errorf("steady stats = %+v, want zero variation and a 0.1 engagement rate", steady)
+	}
+	if steady.Score != 100 {
+		t.Errorf("steady score %v, want 100", steady.Score)
+	}
+
+	bursty := ComputeContentStats("u", dailyTweets(0, 50, 0, 2, 90, 1, 0), 100)
+	if bursty.EngagementCV <= 0 || bursty.Score >= steady.Score {
+		t.Errorf("bursty stats = %+v, want a lower score than %v", bursty, steady.Score)
+	}
+
+	few := ComputeContentStats("u", dailyTweets(10, 10, 10, 10), 100)
+	if few.Tweets != 4 || few.Score != 0 {
+		t.Errorf("stats from %d posts = %+v, want no score", few.Tweets, few)
+	}
+}
+
+func TestScoreUserContentStoresTweetsAndScore(t *testing.T) {
+	a, _ := newTestApp(t)
+	user := fakeUserID(0)
+
+	stats, err := a.ScoreUserContent(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Tweets != fakeTweetsPerUser || stats.Score <= 0 {
+		t.Errorf("stats = %+v, want a score from %d posts", stats, fakeTweetsPerUser)
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM tweets WHERE author_id = ?`, user); got != fakeTweetsPerUser {
+		t.Errorf("stored %d tweets, want %d", got, fakeTweetsPerUser)
+	}
+	if saved := a.GetContentStats(user); saved == nil || saved.Score != stats.Score {
+		t.Errorf("saved stats = %+v, want score %v", saved, stats.Score)
+	}
+}
+
+func TestScoreUserContentReadsOnlyTweetsPerScoreUnderBudget(t *testing.T) {
+	a, fake := newTestApp(t)
+	user := fakeUserID(0)
+
+	fake.mu.Lock()
+	for n := fakeTweetsPerUser; n < 3*tweetsPerScore; n++ {
+		fake.tweets[user] = append(fake.tweets[user], fakeTweet{
+			ID:        strconv.Itoa(900000 + n),
+			CreatedAt: fakeEpoch.Add(-time.Duration(n) * 24 * time.Hour),
+		})
+	}
+	fake.mu.Unlock()
+
+	price := GetUnitPrice(a.db, endpointUserTweets)
+	if err := SetBudget(a.db, Budget{MonthlyLimit: 10 * tweetsPerScore * price, Truncate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.ScoreUserContent(user); err != nil {
+		t.Fatal(err)
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM tweets WHERE author_id = ?`, user); got != tweetsPerScore {
+		t.Errorf("stored %d tweets, want %d", got, tweetsPerScore)
+	}
+}
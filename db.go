@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,101 +15,46 @@ import (
 
 const dbPath = "data.db"
 
-func InitDB() *sql.DB {
-	db, err := sql.Open("sqlite", dbPath)
+func InitDB() (*sql.DB, error) {
+	return openDB(dbPath)
+}
+
+// openDB opens (creating if needed) and migrates the database at path.
+func openDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
 	if err != nil {
-		log.Fatal(fmt.Errorf("opening database: %w", err))
+		return nil, fmt.Errorf("opening database: %w", err)
 	}
 
 	// Enable WAL mode for better concurrent read/write performance
 	_, err = db.Exec("PRAGMA journal_mode=WAL")
 	if err != nil {
-		log.Fatal(fmt.Errorf("setting WAL mode: %w", err))
-	}
-
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
-			id TEXT PRIMARY KEY,
-			username TEXT NOT NULL,
-			name TEXT,
-			description TEXT,
-			followers_count INTEGER,
-			following_count INTEGER,
-			tweet_count INTEGER,
-			listed_count INTEGER,
-			verified INTEGER DEFAULT 0,
-			verified_type TEXT,
-			profile_image_url TEXT,
-			created_at TEXT,
-			location TEXT,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE TABLE IF NOT EXISTS following_snapshots (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			source_user_id TEXT NOT NULL,
-			target_user_id TEXT NOT NULL,
-			fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_snapshots_source ON following_snapshots(source_user_id, fetched_at);
-		CREATE INDEX IF NOT EXISTS idx_snapshots_target ON following_snapshots(target_user_id);
-
-		CREATE TABLE IF NOT EXISTS fetch_logs (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			endpoint TEXT,
-			user_id TEXT,
-			status_code INTEGER,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE TABLE IF NOT EXISTS accounts (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id TEXT NOT NULL UNIQUE,
-			username TEXT NOT NULL,
-			bearer_token TEXT NOT NULL,
-			is_active INTEGER DEFAULT 1,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE TABLE IF NOT EXISTS list_cache (
-			list_id TEXT NOT NULL,
-			owner_user_id TEXT NOT NULL,
-			name TEXT NOT NULL,
-			description TEXT DEFAULT '',
-			member_count INTEGER DEFAULT 0,
-			private INTEGER DEFAULT 0,
-			fetched_at TEXT NOT NULL,
-			PRIMARY KEY (list_id, owner_user_id)
-		);
-
-		CREATE TABLE IF NOT EXISTS list_member_cache (
-			list_id TEXT NOT NULL,
-			user_id TEXT NOT NULL,
-			fetched_at TEXT NOT NULL,
-			PRIMARY KEY (list_id, user_id)
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_list_member_user ON list_member_cache(user_id);
-
-		CREATE TABLE IF NOT EXISTS followers_snapshots (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			source_user_id TEXT NOT NULL,
-			target_user_id TEXT NOT NULL,
-			fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_followers_source ON followers_snapshots(source_user_id, fetched_at);
-		CREATE INDEX IF NOT EXISTS idx_followers_target ON followers_snapshots(target_user_id);
-	`)
-	if err != nil {
-		log.Fatal(fmt.Errorf("creating tables: %w", err))
-	}
-
-	return db
+		return nil, fmt.Errorf("setting WAL mode: %w", err)
+	}
+
+	if err := migrateDB(db); err != nil {
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
+
+	for endpoint, cost := range defaultPrices {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO api_prices (endpoint, unit_cost) VALUES (?, ?)`, endpoint, cost); err != nil {
+			return nil, fmt.Errorf("seeding prices: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// dbExecer is satisfied by both *sql.DB and *sql.Tx.
+type dbExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
 }
 
 func UpsertUser(db *sql.DB, user gen.User) error {
+	return upsertUser(db, user)
+}
+
+func upsertUser(db dbExecer, user gen.User) error {
 	var followersCount, followingCount, tweetCount, listedCount int
 	if user.PublicMetrics != nil {
 		followersCount = user.PublicMetrics.FollowersCount
@@ -117,6 +63,23 @@ func UpsertUser(db *sql.DB, user gen.User) error {
 		listedCount = user.PublicMetrics.ListedCount
 	}
 
+	// Record a history point before overwriting, only when the metrics
+	// differ from what is stored (or the user is new).
+	if user.PublicMetrics != nil {
+		_, err := db.Exec(`
+			INSERT INTO user_metrics_history (user_id, followers_count, following_count, tweet_count, listed_count, recorded_at)
+			SELECT ?, ?, ?, ?, ?, ?
+			WHERE NOT EXISTS (
+				SELECT 1 FROM users WHERE id = ?
+				  AND followers_count = ? AND following_count = ? AND tweet_count = ? AND listed_count = ?
+			)
+		`, user.Id, followersCount, followingCount, tweetCount, listedCount, time.Now().UTC().Format(time.RFC3339),
+			user.Id, followersCount, followingCount, tweetCount, listedCount)
+		if err != nil {
+			return fmt.Errorf("recording metrics history: %w", err)
+		}
+	}
+
 	var verified int
 	if user.Verified != nil && *user.Verified {
 		verified = 1
@@ -128,6 +91,16 @@ func UpsertUser(db *sql.DB, user gen.User) error {
 		createdAt = &s
 	}
 
+	// Without public_metrics the stored counts are kept, not zeroed.
+	metricsSet := ""
+	if user.PublicMetrics != nil {
+		metricsSet = `
+			followers_count = excluded.followers_count,
+			following_count = excluded.following_count,
+			tweet_count = excluded.tweet_count,
+			listed_count = excluded.listed_count,`
+	}
+
 	_, err := db.Exec(`
 		INSERT INTO users (id, username, name, description, followers_count, following_count,
 			tweet_count, listed_count, verified, verified_type, profile_image_url, created_at, location, updated_at)
@@ -135,11 +108,7 @@ func UpsertUser(db *sql.DB, user gen.User) error {
 		ON CONFLICT(id) DO UPDATE SET
 			username = excluded.username,
 			name = excluded.name,
-			description = excluded.description,
-			followers_count = excluded.followers_count,
-			following_count = excluded.following_count,
-			tweet_count = excluded.tweet_count,
-			listed_count = excluded.listed_count,
+			description = excluded.description,`+metricsSet+`
 			verified = excluded.verified,
 			verified_type = excluded.verified_type,
 			profile_image_url = excluded.profile_image_url,
@@ -153,39 +122,39 @@ func UpsertUser(db *sql.DB, user gen.User) error {
 	return err
 }
 
-func SaveSnapshot(db *sql.DB, sourceUserId string, users []gen.User) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("beginning transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	fetchedAt := time.Now().UTC().Format(time.RFC3339)
+// maxQueryIDs caps the IDs bound in one IN (...) list, well below SQLite's
+// limit of 32766 variables per statement.
+const maxQueryIDs = 10000
 
-	stmt, err := tx.Prepare(`
-		INSERT INTO following_snapshots (source_user_id, target_user_id, fetched_at)
-		VALUES (?, ?, ?)
-	`)
-	if err != nil {
-		return fmt.Errorf("preparing statement: %w", err)
+// chunkIDs splits ids into slices of at most maxQueryIDs.
+func chunkIDs(ids []string) [][]string {
+	var chunks [][]string
+	for len(ids) > maxQueryIDs {
+		chunks = append(chunks, ids[:maxQueryIDs])
+		ids = ids[maxQueryIDs:]
 	}
-	defer stmt.Close()
-
-	for _, user := range users {
-		if _, err := stmt.Exec(sourceUserId, user.Id, fetchedAt); err != nil {
-			return fmt.Errorf("inserting snapshot for user %s: %w", user.Id, err)
-		}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
 	}
-
-	return tx.Commit()
+	return chunks
 }
 
-// GetUsersByIDs returns FollowingUser records for a list of user IDs.
+// GetUsersByIDs returns FollowingUser records for a list of user IDs, most
+// followed first.
 func GetUsersByIDs(db *sql.DB, ids []string) ([]FollowingUser, error) {
-	if len(ids) == 0 {
-		return nil, nil
+	var users []FollowingUser
+	for _, chunk := range chunkIDs(ids) {
+		part, err := getUsersByIDChunk(db, chunk)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, part...)
 	}
+	sort.SliceStable(users, func(i, j int) bool { return users[i].FollowersCount > users[j].FollowersCount })
+	return users, nil
+}
 
+func getUsersByIDChunk(db *sql.DB, ids []string) ([]FollowingUser, error) {
 	placeholders := make([]string, len(ids))
 	args := make([]interface{}, len(ids))
 	for i, id := range ids {
@@ -199,7 +168,8 @@ func GetUsersByIDs(db *sql.DB, ids []string) ([]FollowingUser, error) {
 			COALESCE(tweet_count, 0), COALESCE(listed_count, 0),
 			COALESCE(verified, 0), COALESCE(verified_type, ''),
 			COALESCE(profile_image_url, ''), COALESCE(location, ''),
-			COALESCE(created_at, ''), COALESCE(updated_at, '')
+			COALESCE(created_at, ''), COALESCE(updated_at, ''),
+			COALESCE((SELECT score FROM content_scores WHERE user_id = users.id), 0)
 		FROM users WHERE id IN (%s)
 		ORDER BY followers_count DESC
 	`, strings.Join(placeholders, ","))
@@ -217,7 +187,7 @@ func GetUsersByIDs(db *sql.DB, ids []string) ([]FollowingUser, error) {
 		if err := rows.Scan(&u.Id, &u.Username, &u.Name, &u.Description,
 			&u.FollowersCount, &u.FollowingCount, &u.TweetCount, &u.ListedCount,
 			&verified, &u.VerifiedType, &u.ProfileImageUrl, &u.Location,
-			&u.CreatedAt, &u.UpdatedAt); err != nil {
+			&u.CreatedAt, &u.UpdatedAt, &u.StabilityScore); err != nil {
 			continue
 		}
 		u.Verified = verified == 1
@@ -228,16 +198,21 @@ func GetUsersByIDs(db *sql.DB, ids []string) ([]FollowingUser, error) {
 
 // Account represents a tracked Twitter account stored in SQLite.
 type Account struct {
-	ID          int    `json:"id"`
-	UserID      string `json:"user_id"`
-	Username    string `json:"username"`
-	BearerToken string `json:"-"`
-	IsActive    bool   `json:"is_active"`
-	CreatedAt   string `json:"created_at"`
+	ID           int    `json:"id"`
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	BearerToken  string `json:"-"`
+	IsActive     bool   `json:"is_active"`
+	CreatedAt    string `json:"created_at"`
+	HasUserToken bool   `json:"has_user_token"` // OAuth1 or OAuth2 login completed
 }
 
+const accountColumns = `id, user_id, username, bearer_token, is_active, created_at,
+	EXISTS (SELECT 1 FROM oauth1_tokens t WHERE t.account_user_id = accounts.user_id)
+	OR EXISTS (SELECT 1 FROM oauth2_tokens t WHERE t.account_user_id = accounts.user_id)`
+
 func GetAllAccounts(db *sql.DB) ([]Account, error) {
-	rows, err := db.Query(`SELECT id, user_id, username, bearer_token, is_active, created_at FROM accounts ORDER BY created_at ASC`)
+	rows, err := db.Query(`SELECT ` + accountColumns + ` FROM accounts ORDER BY created_at ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +222,7 @@ func GetAllAccounts(db *sql.DB) ([]Account, error) {
 	for rows.Next() {
 		var a Account
 		var isActive int
-		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.BearerToken, &isActive, &a.CreatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.BearerToken, &isActive, &a.CreatedAt, &a.HasUserToken); err != nil {
 			continue
 		}
 		a.IsActive = isActive == 1
@@ -259,8 +234,8 @@ func GetAllAccounts(db *sql.DB) ([]Account, error) {
 func GetAccountByUserID(db *sql.DB, userID string) (*Account, error) {
 	var a Account
 	var isActive int
-	err := db.QueryRow(`SELECT id, user_id, username, bearer_token, is_active, created_at FROM accounts WHERE user_id = ?`, userID).
-		Scan(&a.ID, &a.UserID, &a.Username, &a.BearerToken, &isActive, &a.CreatedAt)
+	err := db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE user_id = ?`, userID).
+		Scan(&a.ID, &a.UserID, &a.Username, &a.BearerToken, &isActive, &a.CreatedAt, &a.HasUserToken)
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +244,7 @@ func GetAccountByUserID(db *sql.DB, userID string) (*Account, error) {
 }
 
 func GetActiveAccounts(db *sql.DB) ([]Account, error) {
-	rows, err := db.Query(`SELECT id, user_id, username, bearer_token, is_active, created_at FROM accounts WHERE is_active = 1 ORDER BY created_at ASC`)
+	rows, err := db.Query(`SELECT ` + accountColumns + ` FROM accounts WHERE is_active = 1 ORDER BY created_at ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -279,7 +254,7 @@ func GetActiveAccounts(db *sql.DB) ([]Account, error) {
 	for rows.Next() {
 		var a Account
 		var isActive int
-		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.BearerToken, &isActive, &a.CreatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.BearerToken, &isActive, &a.CreatedAt, &a.HasUserToken); err != nil {
 			continue
 		}
 		a.IsActive = isActive == 1
@@ -295,10 +270,87 @@ func AddAccount(db *sql.DB, userID, username, bearerToken string) error {
 }
 
 func RemoveAccount(db *sql.DB, userID string) error {
+	for _, table := range []string{"oauth1_tokens", "oauth2_tokens"} {
+		if _, err := db.Exec(`DELETE FROM `+table+` WHERE account_user_id = ?`, userID); err != nil {
+			return err
+		}
+	}
 	_, err := db.Exec(`DELETE FROM accounts WHERE user_id = ?`, userID)
 	return err
 }
 
+// SaveOAuth1Token stores the user access token obtained by an OAuth1 login.
+func SaveOAuth1Token(db *sql.DB, accountUserId, accessToken, accessSecret string) error {
+	_, err := db.Exec(`
+		INSERT INTO oauth1_tokens (account_user_id, access_token, access_secret, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(account_user_id) DO UPDATE SET
+			access_token = excluded.access_token,
+			access_secret = excluded.access_secret,
+			updated_at = excluded.updated_at
+	`, accountUserId, accessToken, accessSecret, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// GetOAuth1Token returns the stored user access token of an account.
+func GetOAuth1Token(db *sql.DB, accountUserId string) (accessToken, accessSecret string, ok bool) {
+	err := db.QueryRow(`SELECT access_token, access_secret FROM oauth1_tokens WHERE account_user_id = ?`, accountUserId).
+		Scan(&accessToken, &accessSecret)
+	return accessToken, accessSecret, err == nil
+}
+
+// SaveOAuth2Token stores (or replaces) the OAuth2 user tokens of an account.
+func SaveOAuth2Token(db *sql.DB, accountUserId string, tok *OAuth2Token) error {
+	var expiresAt string
+	if !tok.ExpiresAt.IsZero() {
+		expiresAt = tok.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	_, err := db.Exec(`
+		INSERT INTO oauth2_tokens (account_user_id, access_token, refresh_token, expires_at, scope, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(account_user_id) DO UPDATE SET
+			access_token = excluded.access_token,
+			refresh_token = excluded.refresh_token,
+			expires_at = excluded.expires_at,
+			scope = excluded.scope,
+			updated_at = excluded.updated_at
+	`, accountUserId, tok.AccessToken, tok.RefreshToken, expiresAt, tok.Scope, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+func GetOAuth2Token(db *sql.DB, accountUserId string) (*OAuth2Token, error) {
+	var tok OAuth2Token
+	var expiresAt string
+	err := db.QueryRow(`
+		SELECT access_token, refresh_token, expires_at, scope FROM oauth2_tokens WHERE account_user_id = ?
+	`, accountUserId).Scan(&tok.AccessToken, &tok.RefreshToken, &expiresAt, &tok.Scope)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != "" {
+		tok.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+	}
+	return &tok, nil
+}
+
+// GetOAuth2TokenAccounts lists accounts with stored OAuth2 tokens.
+func GetOAuth2TokenAccounts(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT account_user_id FROM oauth2_tokens`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 func LogFetch(db *sql.DB, endpoint, userId string, statusCode int) {
 	_, err := db.Exec(`
 		INSERT INTO fetch_logs (endpoint, user_id, status_code)
@@ -309,6 +361,22 @@ func LogFetch(db *sql.DB, endpoint, userId string, statusCode int) {
 	}
 }
 
+// GetLastFetchAt returns when endpoint was last logged for userId.
+func GetLastFetchAt(db *sql.DB, endpoint, userId string) (time.Time, bool) {
+	var createdAt string
+	err := db.QueryRow(`
+		SELECT COALESCE(MAX(created_at), '') FROM fetch_logs WHERE endpoint = ? AND user_id = ?
+	`, endpoint, userId).Scan(&createdAt)
+	if err != nil || createdAt == "" {
+		return time.Time{}, false
+	}
+	t, err := parseDBTime(createdAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
 // --- Cache freshness checks (30-day threshold) ---
 
 func IsFollowersCacheFresh(db *sql.DB, sourceUserId string) bool {
@@ -326,33 +394,6 @@ func IsFollowersCacheFresh(db *sql.DB, sourceUserId string) bool {
 	return time.Since(t) < 30*24*time.Hour
 }
 
-func SaveFollowersSnapshot(db *sql.DB, sourceUserId string, users []gen.User) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("beginning transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	fetchedAt := time.Now().UTC().Format(time.RFC3339)
-
-	stmt, err := tx.Prepare(`
-		INSERT INTO followers_snapshots (source_user_id, target_user_id, fetched_at)
-		VALUES (?, ?, ?)
-	`)
-	if err != nil {
-		return fmt.Errorf("preparing statement: %w", err)
-	}
-	defer stmt.Close()
-
-	for _, user := range users {
-		if _, err := stmt.Exec(sourceUserId, user.Id, fetchedAt); err != nil {
-			return fmt.Errorf("inserting snapshot for user %s: %w", user.Id, err)
-		}
-	}
-
-	return tx.Commit()
-}
-
 func IsFollowingCacheFresh(db *sql.DB, sourceUserId string) bool {
 	var fetchedAt string
 	err := db.QueryRow(`
@@ -432,7 +473,7 @@ func SaveListCache(db *sql.DB, ownerUserId string, lists []TwitterList) error {
 }
 
 func GetCachedLists(db *sql.DB, ownerUserId string) []TwitterList {
-	rows, err := db.Query(`SELECT list_id, name, description, member_count, private FROM list_cache WHERE owner_user_id = ?`, ownerUserId)
+	rows, err := db.Query(`SELECT list_id, name, COALESCE(description, ''), COALESCE(member_count, 0), private FROM list_cache WHERE owner_user_id = ?`, ownerUserId)
 	if err != nil {
 		return nil
 	}
@@ -451,33 +492,6 @@ func GetCachedLists(db *sql.DB, ownerUserId string) []TwitterList {
 	return lists
 }
 
-func SaveListMemberCache(db *sql.DB, listId string, userIDs []string) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	_, err = tx.Exec(`DELETE FROM list_member_cache WHERE list_id = ?`, listId)
-	if err != nil {
-		return err
-	}
-
-	fetchedAt := time.Now().UTC().Format(time.RFC3339)
-	stmt, err := tx.Prepare(`INSERT INTO list_member_cache (list_id, user_id, fetched_at) VALUES (?, ?, ?)`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	for _, uid := range userIDs {
-		if _, err := stmt.Exec(listId, uid, fetchedAt); err != nil {
-			return err
-		}
-	}
-	return tx.Commit()
-}
-
 func GetCachedListMemberIDs(db *sql.DB, listId string) []string {
 	rows, err := db.Query(`SELECT user_id FROM list_member_cache WHERE list_id = ?`, listId)
 	if err != nil {
@@ -502,6 +516,16 @@ func GetListNamesForUsers(db *sql.DB, ownerUserId string, userIDs []string) map[
 		return nil
 	}
 
+	result := make(map[string][]string)
+	for _, chunk := range chunkIDs(userIDs) {
+		if err := listNamesForUserChunk(db, ownerUserId, chunk, result); err != nil {
+			return nil
+		}
+	}
+	return result
+}
+
+func listNamesForUserChunk(db *sql.DB, ownerUserId string, userIDs []string, result map[string][]string) error {
 	placeholders := make([]string, len(userIDs))
 	args := make([]interface{}, 0, len(userIDs)+1)
 	args = append(args, ownerUserId)
@@ -520,11 +544,10 @@ func GetListNamesForUsers(db *sql.DB, ownerUserId string, userIDs []string) map[
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil
+		return err
 	}
 	defer rows.Close()
 
-	result := make(map[string][]string)
 	for rows.Next() {
 		var uid, listName string
 		if err := rows.Scan(&uid, &listName); err != nil {
@@ -532,5 +555,1116 @@ func GetListNamesForUsers(db *sql.DB, ownerUserId string, userIDs []string) map[
 		}
 		result[uid] = append(result[uid], listName)
 	}
-	return result
+	return rows.Err()
+}
+
+// --- Rate limits ---
+
+func SaveRateLimit(db *sql.DB, rl RateLimit) error {
+	_, err := db.Exec(`
+		INSERT INTO rate_limits (endpoint, token_key, rate_limit, remaining, reset_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(endpoint, token_key) DO UPDATE SET
+			rate_limit = excluded.rate_limit,
+			remaining = excluded.remaining,
+			reset_at = excluded.reset_at,
+			updated_at = excluded.updated_at
+	`, rl.Endpoint, rl.TokenKey, rl.Limit, rl.Remaining, rl.ResetAt, rl.UpdatedAt)
+	return err
+}
+
+func GetRateLimits(db *sql.DB) ([]RateLimit, error) {
+	rows, err := db.Query(`SELECT endpoint, token_key, rate_limit, remaining, reset_at, updated_at FROM rate_limits ORDER BY endpoint, token_key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []RateLimit
+	for rows.Next() {
+		var rl RateLimit
+		if err := rows.Scan(&rl.Endpoint, &rl.TokenKey, &rl.Limit, &rl.Remaining, &rl.ResetAt, &rl.UpdatedAt); err != nil {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, rl.ResetAt); err == nil {
+			rl.reset = t
+		}
+		limits = append(limits, rl)
+	}
+	return limits, nil
+}
+
+// --- Fetch jobs (resumable pagination) ---
+
+// FetchJob is an in-progress paginated fetch. It exists from the first page
+// until the collected users are committed, so a failed run can resume from
+// PaginationToken instead of paying again for pages already fetched.
+type FetchJob struct {
+	ID              int    `json:"id"`
+	Endpoint        string `json:"endpoint"`
+	AccountUserID   string `json:"account_user_id"`
+	TargetID        string `json:"target_id"`
+	PaginationToken string `json:"pagination_token"`
+	Pages           int    `json:"pages"`
+	Items           int    `json:"items"`
+	LastError       string `json:"last_error"`
+	StartedAt       string `json:"started_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+const fetchJobColumns = `id, endpoint, account_user_id, target_id, COALESCE(pagination_token, ''),
+	pages, items, COALESCE(last_error, ''), started_at, updated_at`
+
+func scanFetchJob(row interface{ Scan(...interface{}) error }) (*FetchJob, error) {
+	var j FetchJob
+	err := row.Scan(&j.ID, &j.Endpoint, &j.AccountUserID, &j.TargetID, &j.PaginationToken,
+		&j.Pages, &j.Items, &j.LastError, &j.StartedAt, &j.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// GetOrCreateFetchJob returns the pending job for endpoint/account/target,
+// creating an empty one if none exists.
+func GetOrCreateFetchJob(db *sql.DB, endpoint, accountUserId, targetId string) (*FetchJob, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO fetch_jobs (endpoint, account_user_id, target_id, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, endpoint, accountUserId, targetId, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetFetchJob(db, endpoint, accountUserId, targetId)
+}
+
+// GetFetchJob returns the pending job for endpoint/account/target or sql.ErrNoRows.
+func GetFetchJob(db *sql.DB, endpoint, accountUserId, targetId string) (*FetchJob, error) {
+	return scanFetchJob(db.QueryRow(`SELECT `+fetchJobColumns+` FROM fetch_jobs
+		WHERE endpoint = ? AND account_user_id = ? AND target_id = ?`, endpoint, accountUserId, targetId))
+}
+
+func GetFetchJobs(db *sql.DB, accountUserId string) ([]FetchJob, error) {
+	rows, err := db.Query(`SELECT `+fetchJobColumns+` FROM fetch_jobs WHERE account_user_id = ? ORDER BY started_at ASC`, accountUserId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []FetchJob
+	for rows.Next() {
+		j, err := scanFetchJob(rows)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, nil
+}
+
+// SaveFetchJobPage upserts the users of one page, stages them for the
+// job's snapshot and advances the cursor in a single transaction, so a
+// crash never leaves a page half-stored. nextToken is nil after the last page.
+func SaveFetchJobPage(db *sql.DB, jobID int, users []gen.User, nextToken *string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO fetch_job_users (job_id, user_id) VALUES (?, ?)`)
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, user := range users {
+		if err := upsertUser(tx, user); err != nil {
+			return fmt.Errorf("upserting user %s: %w", user.Id, err)
+		}
+		if _, err := stmt.Exec(jobID, user.Id); err != nil {
+			return fmt.Errorf("staging user %s: %w", user.Id, err)
+		}
+	}
+
+	token := ""
+	if nextToken != nil {
+		token = *nextToken
+	}
+	_, err = tx.Exec(`
+		UPDATE fetch_jobs SET
+			pagination_token = ?,
+			pages = pages + 1,
+			items = (SELECT COUNT(*) FROM fetch_job_users WHERE job_id = ?),
+			last_error = '',
+			updated_at = ?
+		WHERE id = ?
+	`, token, jobID, time.Now().UTC().Format(time.RFC3339), jobID)
+	if err != nil {
+		return fmt.Errorf("updating job cursor: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func FailFetchJob(db *sql.DB, jobID int, cause error) {
+	_, err := db.Exec(`UPDATE fetch_jobs SET last_error = ?, updated_at = ? WHERE id = ?`,
+		cause.Error(), time.Now().UTC().Format(time.RFC3339), jobID)
+	if err != nil {
+		log.Printf("Warning: failed to record job error: %v", err)
+	}
+}
+
+func DeleteFetchJob(db *sql.DB, jobID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM fetch_job_users WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM fetch_jobs WHERE id = ?`, jobID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CompleteFetchJobSnapshot publishes the users staged by a job as one snapshot
+// in following_snapshots or followers_snapshots and removes the job, all in a
+// single transaction so readers never see a partial snapshot.
+func CompleteFetchJobSnapshot(db *sql.DB, jobID int, table, sourceUserId string) (int, error) {
+	if !isSnapshotTable(table) {
+		return 0, fmt.Errorf("unknown snapshot table %q", table)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	fetchedAt := time.Now().UTC().Format(time.RFC3339)
+	res, err := tx.Exec(fmt.Sprintf(`
+		INSERT INTO %s (source_user_id, target_user_id, fetched_at)
+		SELECT ?, user_id, ? FROM fetch_job_users WHERE job_id = ?
+	`, table), sourceUserId, fetchedAt, jobID)
+	if err != nil {
+		return 0, fmt.Errorf("inserting snapshot: %w", err)
+	}
+	n, _ := res.RowsAffected()
+
+	if _, err := tx.Exec(`DELETE FROM fetch_job_users WHERE job_id = ?`, jobID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`DELETE FROM fetch_jobs WHERE id = ?`, jobID); err != nil {
+		return 0, err
+	}
+	return int(n), tx.Commit()
+}
+
+// CompleteFetchJobListMembers replaces the member cache of listId with the
+// users collected by a job and removes the job.
+func CompleteFetchJobListMembers(db *sql.DB, jobID int, listId string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM list_member_cache WHERE list_id = ?`, listId); err != nil {
+		return 0, err
+	}
+
+	fetchedAt := time.Now().UTC().Format(time.RFC3339)
+	res, err := tx.Exec(`
+		INSERT INTO list_member_cache (list_id, user_id, fetched_at)
+		SELECT ?, user_id, ? FROM fetch_job_users WHERE job_id = ?
+	`, listId, fetchedAt, jobID)
+	if err != nil {
+		return 0, fmt.Errorf("inserting list members: %w", err)
+	}
+	n, _ := res.RowsAffected()
+
+	if _, err := tx.Exec(`DELETE FROM fetch_job_users WHERE job_id = ?`, jobID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`DELETE FROM fetch_jobs WHERE id = ?`, jobID); err != nil {
+		return 0, err
+	}
+	return int(n), tx.Commit()
+}
+
+// --- API spend ledger ---
+
+// APICall is one HTTP request sent to the X API, with what it cost.
+type APICall struct {
+	ID            int     `json:"id"`
+	Endpoint      string  `json:"endpoint"`
+	AccountUserID string  `json:"account_user_id"`
+	TokenKey      string  `json:"token_key"`
+	StatusCode    int     `json:"status_code"`
+	Resources     int     `json:"resources"`
+	Cost          float64 `json:"cost"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// APIPrice is the cost in USD of one resource read from an endpoint.
+type APIPrice struct {
+	Endpoint string  `json:"endpoint"`
+	UnitCost float64 `json:"unit_cost"`
+}
+
+// SpendRow aggregates api_calls under one key (day, month, account or endpoint).
+type SpendRow struct {
+	Key       string  `json:"key"`
+	Requests  int     `json:"requests"`
+	Resources int     `json:"resources"`
+	Cost      float64 `json:"cost"`
+}
+
+func SaveAPICall(db *sql.DB, call APICall) error {
+	if call.CreatedAt == "" {
+		call.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	_, err := db.Exec(`
+		INSERT INTO api_calls (endpoint, account_user_id, token_key, status_code, resources, cost, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, call.Endpoint, call.AccountUserID, call.TokenKey, call.StatusCode, call.Resources, call.Cost, call.CreatedAt)
+	return err
+}
+
+// AttributeAPICalls assigns calls made with token since since and no account
+// yet, such as the lookup that resolves a new account, to accountUserId.
+func AttributeAPICalls(db *sql.DB, token, accountUserId, since string) error {
+	_, err := db.Exec(`
+		UPDATE api_calls SET account_user_id = ?
+		WHERE account_user_id = '' AND token_key = ? AND created_at >= ?
+	`, accountUserId, tokenFingerprint(token), since)
+	return err
+}
+
+// GetUnitPrice returns the price per resource for endpoint, 0 if unpriced.
+func GetUnitPrice(db *sql.DB, endpoint string) float64 {
+	var cost float64
+	db.QueryRow(`SELECT unit_cost FROM api_prices WHERE endpoint = ?`, endpoint).Scan(&cost)
+	return cost
+}
+
+func GetPrices(db *sql.DB) ([]APIPrice, error) {
+	rows, err := db.Query(`SELECT endpoint, unit_cost FROM api_prices ORDER BY endpoint`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []APIPrice
+	for rows.Next() {
+		var p APIPrice
+		if err := rows.Scan(&p.Endpoint, &p.UnitCost); err != nil {
+			continue
+		}
+		prices = append(prices, p)
+	}
+	return prices, nil
+}
+
+func SetPrice(db *sql.DB, endpoint string, unitCost float64) error {
+	_, err := db.Exec(`
+		INSERT INTO api_prices (endpoint, unit_cost) VALUES (?, ?)
+		ON CONFLICT(endpoint) DO UPDATE SET unit_cost = excluded.unit_cost
+	`, endpoint, unitCost)
+	return err
+}
+
+// GetSpendSummary groups api_calls since the given RFC3339 time by keyExpr,
+// which must be one of the spend* group expressions below.
+func GetSpendSummary(db *sql.DB, keyExpr, since string) ([]SpendRow, error) {
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT %s AS k, COUNT(*), COALESCE(SUM(resources), 0), COALESCE(SUM(cost), 0)
+		FROM api_calls
+		WHERE created_at >= ?
+		GROUP BY k
+		ORDER BY k
+	`, keyExpr), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []SpendRow
+	for rows.Next() {
+		var r SpendRow
+		if err := rows.Scan(&r.Key, &r.Requests, &r.Resources, &r.Cost); err != nil {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+const (
+	spendByDay      = "substr(created_at, 1, 10)"
+	spendByMonth    = "substr(created_at, 1, 7)"
+	spendByAccount  = "account_user_id"
+	spendByEndpoint = "endpoint"
+)
+
+// --- Budgets ---
+
+// Budget caps monthly API spend. Scope is "" for the global budget or an
+// account user ID. With Truncate set, fetches that would exceed the budget
+// are cut short to fit instead of being refused.
+type Budget struct {
+	Scope        string  `json:"scope"`
+	MonthlyLimit float64 `json:"monthly_limit"`
+	Truncate     bool    `json:"truncate"`
+}
+
+func GetBudget(db *sql.DB, scope string) (*Budget, error) {
+	var b Budget
+	var truncate int
+	err := db.QueryRow(`SELECT scope, monthly_limit, truncate FROM budgets WHERE scope = ?`, scope).
+		Scan(&b.Scope, &b.MonthlyLimit, &truncate)
+	if err != nil {
+		return nil, err
+	}
+	b.Truncate = truncate == 1
+	return &b, nil
+}
+
+func GetBudgets(db *sql.DB) ([]Budget, error) {
+	rows, err := db.Query(`SELECT scope, monthly_limit, truncate FROM budgets ORDER BY scope`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []Budget
+	for rows.Next() {
+		var b Budget
+		var truncate int
+		if err := rows.Scan(&b.Scope, &b.MonthlyLimit, &truncate); err != nil {
+			continue
+		}
+		b.Truncate = truncate == 1
+		budgets = append(budgets, b)
+	}
+	return budgets, nil
+}
+
+func SetBudget(db *sql.DB, b Budget) error {
+	truncate := 0
+	if b.Truncate {
+		truncate = 1
+	}
+	_, err := db.Exec(`
+		INSERT INTO budgets (scope, monthly_limit, truncate) VALUES (?, ?, ?)
+		ON CONFLICT(scope) DO UPDATE SET monthly_limit = excluded.monthly_limit, truncate = excluded.truncate
+	`, b.Scope, b.MonthlyLimit, truncate)
+	return err
+}
+
+func DeleteBudget(db *sql.DB, scope string) error {
+	_, err := db.Exec(`DELETE FROM budgets WHERE scope = ?`, scope)
+	return err
+}
+
+// GetSpendSince sums api_calls cost since the given RFC3339 time, for one
+// account or for all accounts when accountUserId is "".
+func GetSpendSince(db *sql.DB, accountUserId, since string) float64 {
+	var total float64
+	if accountUserId == "" {
+		db.QueryRow(`SELECT COALESCE(SUM(cost), 0) FROM api_calls WHERE created_at >= ?`, since).Scan(&total)
+	} else {
+		db.QueryRow(`SELECT COALESCE(SUM(cost), 0) FROM api_calls WHERE account_user_id = ? AND created_at >= ?`,
+			accountUserId, since).Scan(&total)
+	}
+	return total
+}
+
+// GetUserCounts returns the cached followers/following counts of a user.
+func GetUserCounts(db *sql.DB, userId string) (followers, following int, ok bool) {
+	err := db.QueryRow(`SELECT COALESCE(followers_count, 0), COALESCE(following_count, 0) FROM users WHERE id = ?`, userId).
+		Scan(&followers, &following)
+	return followers, following, err == nil
+}
+
+// GetCachedListMemberCount returns list_cache.member_count for a list.
+func GetCachedListMemberCount(db *sql.DB, listId string) (int, bool) {
+	var n int
+	err := db.QueryRow(`SELECT member_count FROM list_cache WHERE list_id = ? LIMIT 1`, listId).Scan(&n)
+	return n, err == nil
+}
+
+// --- Follow actions ---
+
+const (
+	followStatusFollowed = "followed"
+	followStatusPending  = "pending"
+	followStatusFailed   = "failed"
+)
+
+// FollowAction is one follow request sent on behalf of an account.
+type FollowAction struct {
+	ID            int    `json:"id"`
+	AccountUserID string `json:"account_user_id"`
+	TargetUserID  string `json:"target_user_id"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func SaveFollowAction(db *sql.DB, action *FollowAction) error {
+	res, err := db.Exec(`
+		INSERT INTO follow_actions (account_user_id, target_user_id, status, error, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, action.AccountUserID, action.TargetUserID, action.Status, action.Error, action.CreatedAt)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	action.ID = int(id)
+	return nil
+}
+
+// GetFollowActions returns the latest follow actions of an account, newest first.
+func GetFollowActions(db *sql.DB, accountUserId string, limit int) ([]FollowAction, error) {
+	rows, err := db.Query(`
+		SELECT id, account_user_id, target_user_id, status, COALESCE(error, ''), created_at
+		FROM follow_actions
+		WHERE account_user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, accountUserId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []FollowAction
+	for rows.Next() {
+		var f FollowAction
+		if err := rows.Scan(&f.ID, &f.AccountUserID, &f.TargetUserID, &f.Status, &f.Error, &f.CreatedAt); err != nil {
+			continue
+		}
+		actions = append(actions, f)
+	}
+	return actions, nil
+}
+
+// HasFollowed reports whether the account already followed (or requested to
+// follow) targetUserId through the app.
+func HasFollowed(db *sql.DB, accountUserId, targetUserId string) bool {
+	var n int
+	db.QueryRow(`
+		SELECT COUNT(*) FROM follow_actions
+		WHERE account_user_id = ? AND target_user_id = ? AND status IN (?, ?)
+	`, accountUserId, targetUserId, followStatusFollowed, followStatusPending).Scan(&n)
+	return n > 0
+}
+
+// GetFollowedTargetIDs returns every user the account followed through the app.
+func GetFollowedTargetIDs(db *sql.DB, accountUserId string) ([]string, error) {
+	return queryStrings(db, `
+		SELECT DISTINCT target_user_id FROM follow_actions
+		WHERE account_user_id = ? AND status IN (?, ?)
+	`, accountUserId, followStatusFollowed, followStatusPending)
+}
+
+// --- Follow queue ---
+
+const (
+	queueStatusQueued  = "queued"
+	queueStatusDone    = "done"
+	queueStatusSkipped = "skipped"
+	queueStatusFailed  = "failed"
+)
+
+// FollowQueueItem is a user waiting to be followed by an account.
+type FollowQueueItem struct {
+	ID            int            `json:"id"`
+	AccountUserID string         `json:"account_user_id"`
+	TargetUserID  string         `json:"target_user_id"`
+	Status        string         `json:"status"`
+	Attempts      int            `json:"attempts"`
+	LastError     string         `json:"last_error,omitempty"`
+	AddedAt       string         `json:"added_at"`
+	ProcessedAt   string         `json:"processed_at,omitempty"`
+	User          *FollowingUser `json:"user,omitempty"`
+}
+
+// EnqueueFollow adds targetUserId to the account's queue, or re-queues it
+// after a failure. It returns false when the user is already queued or done.
+func EnqueueFollow(db *sql.DB, accountUserId, targetUserId string) (bool, error) {
+	res, err := db.Exec(`
+		INSERT INTO follow_queue (account_user_id, target_user_id, status, added_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(account_user_id, target_user_id) DO UPDATE SET
+			status = excluded.status,
+			last_error = '',
+			added_at = excluded.added_at,
+			processed_at = ''
+		WHERE follow_queue.status = ?
+	`, accountUserId, targetUserId, queueStatusQueued, time.Now().UTC().Format(time.RFC3339), queueStatusFailed)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetFollowQueue returns queued items oldest first, then processed ones newest first.
+func GetFollowQueue(db *sql.DB, accountUserId string) ([]FollowQueueItem, error) {
+	rows, err := db.Query(`
+		SELECT id, account_user_id, target_user_id, status, attempts,
+			COALESCE(last_error, ''), added_at, COALESCE(processed_at, '')
+		FROM follow_queue
+		WHERE account_user_id = ?
+		ORDER BY status = ? DESC,
+			CASE WHEN status = ? THEN added_at END ASC,
+			processed_at DESC
+		LIMIT 500
+	`, accountUserId, queueStatusQueued, queueStatusQueued)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []FollowQueueItem
+	for rows.Next() {
+		var q FollowQueueItem
+		if err := rows.Scan(&q.ID, &q.AccountUserID, &q.TargetUserID, &q.Status, &q.Attempts,
+			&q.LastError, &q.AddedAt, &q.ProcessedAt); err != nil {
+			continue
+		}
+		items = append(items, q)
+	}
+	return items, nil
+}
+
+// NextQueuedFollow returns the oldest queued item of an account, or sql.ErrNoRows.
+func NextQueuedFollow(db *sql.DB, accountUserId string) (*FollowQueueItem, error) {
+	var q FollowQueueItem
+	err := db.QueryRow(`
+		SELECT id, account_user_id, target_user_id, status, attempts,
+			COALESCE(last_error, ''), added_at, COALESCE(processed_at, '')
+		FROM follow_queue
+		WHERE account_user_id = ? AND status = ?
+		ORDER BY added_at ASC, id ASC
+		LIMIT 1
+	`, accountUserId, queueStatusQueued).Scan(&q.ID, &q.AccountUserID, &q.TargetUserID, &q.Status,
+		&q.Attempts, &q.LastError, &q.AddedAt, &q.ProcessedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// UpdateFollowQueueItem records an attempt. Items left queued keep their
+// place; any other status marks them processed.
+func UpdateFollowQueueItem(db *sql.DB, id int, status, lastError string) error {
+	processedAt := ""
+	if status != queueStatusQueued {
+		processedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	_, err := db.Exec(`
+		UPDATE follow_queue
+		SET status = ?, last_error = ?, processed_at = ?, attempts = attempts + 1
+		WHERE id = ?
+	`, status, lastError, processedAt, id)
+	return err
+}
+
+func DeleteFollowQueueItem(db *sql.DB, accountUserId string, id int) error {
+	_, err := db.Exec(`DELETE FROM follow_queue WHERE id = ? AND account_user_id = ?`, id, accountUserId)
+	return err
+}
+
+// GetQueuedFollowAccounts returns every account with at least one queued item.
+func GetQueuedFollowAccounts(db *sql.DB) ([]string, error) {
+	return queryStrings(db, `SELECT DISTINCT account_user_id FROM follow_queue WHERE status = ?`, queueStatusQueued)
+}
+
+// FollowSchedule is the per-account daily follow cap and the earliest time
+// the next queued follow may be sent.
+type FollowSchedule struct {
+	AccountUserID string `json:"account_user_id"`
+	DailyLimit    int    `json:"daily_limit"`
+	NextAt        string `json:"next_at"`
+	FollowedToday int    `json:"followed_today"`
+	Queued        int    `json:"queued"`
+}
+
+func GetFollowSchedule(db *sql.DB, accountUserId string) FollowSchedule {
+	s := FollowSchedule{AccountUserID: accountUserId, DailyLimit: defaultDailyFollows}
+	db.QueryRow(`SELECT daily_limit, COALESCE(next_at, '') FROM follow_schedule WHERE account_user_id = ?`,
+		accountUserId).Scan(&s.DailyLimit, &s.NextAt)
+	db.QueryRow(`SELECT COUNT(*) FROM follow_queue WHERE account_user_id = ? AND status = ?`,
+		accountUserId, queueStatusQueued).Scan(&s.Queued)
+	return s
+}
+
+func SetFollowDailyLimit(db *sql.DB, accountUserId string, limit int) error {
+	_, err := db.Exec(`
+		INSERT INTO follow_schedule (account_user_id, daily_limit) VALUES (?, ?)
+		ON CONFLICT(account_user_id) DO UPDATE SET daily_limit = excluded.daily_limit
+	`, accountUserId, limit)
+	return err
+}
+
+func SetFollowNextAt(db *sql.DB, accountUserId string, nextAt time.Time) error {
+	_, err := db.Exec(`
+		INSERT INTO follow_schedule (account_user_id, daily_limit, next_at) VALUES (?, ?, ?)
+		ON CONFLICT(account_user_id) DO UPDATE SET next_at = excluded.next_at
+	`, accountUserId, defaultDailyFollows, nextAt.UTC().Format(time.RFC3339))
+	return err
+}
+
+// CountFollowsSince counts follows sent by the account that went through
+// (followed or pending) since the given RFC3339 time.
+func CountFollowsSince(db *sql.DB, accountUserId, since string) int {
+	var n int
+	db.QueryRow(`
+		SELECT COUNT(*) FROM follow_actions
+		WHERE account_user_id = ? AND created_at >= ? AND status IN (?, ?)
+	`, accountUserId, since, followStatusFollowed, followStatusPending).Scan(&n)
+	return n
+}
+
+// --- Recommendation seeds ---
+
+// Seed is an account whose following list feeds recommendations.
+type Seed struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Following int    `json:"following_count"`
+	FetchedAt string `json:"fetched_at"`
+	AddedAt   string `json:"added_at"`
+}
+
+func AddSeed(db *sql.DB, accountUserId, seedUserId string) error {
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO seed_accounts (account_user_id, seed_user_id, added_at) VALUES (?, ?, ?)
+	`, accountUserId, seedUserId, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+func RemoveSeed(db *sql.DB, accountUserId, seedUserId string) error {
+	_, err := db.Exec(`DELETE FROM seed_accounts WHERE account_user_id = ? AND seed_user_id = ?`,
+		accountUserId, seedUserId)
+	return err
+}
+
+// GetSeeds returns the seeds of an account with the time their following
+// list was last fetched (empty if never).
+func GetSeeds(db *sql.DB, accountUserId string) ([]Seed, error) {
+	rows, err := db.Query(`
+		SELECT s.seed_user_id, COALESCE(u.username, ''), COALESCE(u.name, ''),
+			COALESCE(u.following_count, 0),
+			COALESCE((SELECT MAX(fetched_at) FROM following_snapshots WHERE source_user_id = s.seed_user_id), ''),
+			s.added_at
+		FROM seed_accounts s
+		LEFT JOIN users u ON u.id = s.seed_user_id
+		WHERE s.account_user_id = ?
+		ORDER BY s.added_at ASC
+	`, accountUserId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seeds []Seed
+	for rows.Next() {
+		var s Seed
+		if err := rows.Scan(&s.UserID, &s.Username, &s.Name, &s.Following, &s.FetchedAt, &s.AddedAt); err != nil {
+			continue
+		}
+		seeds = append(seeds, s)
+	}
+	return seeds, nil
+}
+
+// CountSeedFollowing counts, per target, how many of seedIds follow it in
+// their latest following snapshot.
+func CountSeedFollowing(db *sql.DB, seedIds []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(seedIds) == 0 {
+		return counts, nil
+	}
+
+	placeholders := make([]string, len(seedIds))
+	args := make([]interface{}, len(seedIds))
+	for i, id := range seedIds {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT s.target_user_id, COUNT(DISTINCT s.source_user_id)
+		FROM following_snapshots s
+		JOIN (
+			SELECT source_user_id, MAX(fetched_at) AS fetched_at
+			FROM following_snapshots
+			WHERE source_user_id IN (%s)
+			GROUP BY source_user_id
+		) latest ON latest.source_user_id = s.source_user_id AND latest.fetched_at = s.fetched_at
+		GROUP BY s.target_user_id
+	`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			continue
+		}
+		counts[id] = n
+	}
+	return counts, nil
+}
+
+// --- Tweets and content scores ---
+
+// StoredTweet is a post with the public metrics seen at its last fetch.
+type StoredTweet struct {
+	ID              string    `json:"id"`
+	AuthorID        string    `json:"author_id"`
+	Text            string    `json:"text"`
+	CreatedAt       time.Time `json:"created_at"`
+	LikeCount       int       `json:"like_count"`
+	RetweetCount    int       `json:"retweet_count"`
+	ReplyCount      int       `json:"reply_count"`
+	QuoteCount      int       `json:"quote_count"`
+	ImpressionCount int       `json:"impression_count"`
+}
+
+// Engagements is the sum of all public interactions with the post.
+func (t StoredTweet) Engagements() int {
+	return t.LikeCount + t.RetweetCount + t.ReplyCount + t.QuoteCount
+}
+
+// SaveTweets upserts tweets by authorId, refreshing their metrics.
+func SaveTweets(db *sql.DB, authorId string, tweets []gen.Tweet) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	fetchedAt := time.Now().UTC().Format(time.RFC3339)
+	for _, t := range tweets {
+		if t.CreatedAt == nil {
+			continue
+		}
+		var likes, retweets, replies, quotes, impressions int
+		if m := t.PublicMetrics; m != nil {
+			likes, retweets, replies = m.LikeCount, m.RetweetCount, m.ReplyCount
+			impressions = int(m.ImpressionCount)
+			if m.QuoteCount != nil {
+				quotes = *m.QuoteCount
+			}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO tweets (id, author_id, text, created_at, like_count, retweet_count,
+				reply_count, quote_count, impression_count, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				like_count = excluded.like_count,
+				retweet_count = excluded.retweet_count,
+				reply_count = excluded.reply_count,
+				quote_count = excluded.quote_count,
+				impression_count = excluded.impression_count,
+				fetched_at = excluded.fetched_at
+		`, t.Id, authorId, t.Text, t.CreatedAt.UTC().Format(time.RFC3339),
+			likes, retweets, replies, quotes, impressions, fetchedAt)
+		if err != nil {
+			return fmt.Errorf("saving tweet %s: %w", t.Id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTweets returns the newest limit tweets of authorId, newest first.
+func GetTweets(db *sql.DB, authorId string, limit int) ([]StoredTweet, error) {
+	rows, err := db.Query(`
+		SELECT id, author_id, COALESCE(text, ''), created_at, like_count, retweet_count,
+			reply_count, quote_count, impression_count
+		FROM tweets
+		WHERE author_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, authorId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tweets []StoredTweet
+	for rows.Next() {
+		var t StoredTweet
+		var createdAt string
+		if err := rows.Scan(&t.ID, &t.AuthorID, &t.Text, &createdAt, &t.LikeCount, &t.RetweetCount,
+			&t.ReplyCount, &t.QuoteCount, &t.ImpressionCount); err != nil {
+			continue
+		}
+		if t.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			continue
+		}
+		tweets = append(tweets, t)
+	}
+	return tweets, nil
+}
+
+func SaveContentStats(db *sql.DB, stats ContentStats) error {
+	_, err := db.Exec(`
+		INSERT INTO content_scores (user_id, tweets, posts_per_week, cadence_cv, engagement_rate,
+			engagement_cv, score, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			tweets = excluded.tweets,
+			posts_per_week = excluded.posts_per_week,
+			cadence_cv = excluded.cadence_cv,
+			engagement_rate = excluded.engagement_rate,
+			engagement_cv = excluded.engagement_cv,
+			score = excluded.score,
+			computed_at = excluded.computed_at
+	`, stats.UserID, stats.Tweets, stats.PostsPerWeek, stats.CadenceCV, stats.EngagementRate,
+		stats.EngagementCV, stats.Score, stats.ComputedAt)
+	return err
+}
+
+func GetContentStats(db *sql.DB, userId string) (*ContentStats, error) {
+	var s ContentStats
+	err := db.QueryRow(`
+		SELECT user_id, tweets, posts_per_week, cadence_cv, engagement_rate, engagement_cv, score, computed_at
+		FROM content_scores WHERE user_id = ?
+	`, userId).Scan(&s.UserID, &s.Tweets, &s.PostsPerWeek, &s.CadenceCV, &s.EngagementRate,
+		&s.EngagementCV, &s.Score, &s.ComputedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// --- User metrics history ---
+
+// MetricPoint is a user's public metrics as first seen at At.
+type MetricPoint struct {
+	At        string `json:"at"`
+	Followers int    `json:"followers_count"`
+	Following int    `json:"following_count"`
+	Tweets    int    `json:"tweet_count"`
+	Listed    int    `json:"listed_count"`
+}
+
+// GetUserMetricsHistory returns every recorded change of userId, oldest first.
+func GetUserMetricsHistory(db *sql.DB, userId string) ([]MetricPoint, error) {
+	rows, err := db.Query(`
+		SELECT recorded_at, COALESCE(followers_count, 0), COALESCE(following_count, 0),
+			COALESCE(tweet_count, 0), COALESCE(listed_count, 0)
+		FROM user_metrics_history
+		WHERE user_id = ?
+		ORDER BY recorded_at ASC, id ASC
+	`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []MetricPoint
+	for rows.Next() {
+		var p MetricPoint
+		if err := rows.Scan(&p.At, &p.Followers, &p.Following, &p.Tweets, &p.Listed); err != nil {
+			continue
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// GetSnapshotMetricsHistory returns the metric history of every target in
+// the latest snapshot of sourceUserId, keyed by user, oldest first.
+func GetSnapshotMetricsHistory(db *sql.DB, table, sourceUserId string) (map[string][]MetricPoint, error) {
+	if !isSnapshotTable(table) {
+		return nil, fmt.Errorf("unknown snapshot table %q", table)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT h.user_id, h.recorded_at, COALESCE(h.followers_count, 0), COALESCE(h.following_count, 0),
+			COALESCE(h.tweet_count, 0), COALESCE(h.listed_count, 0)
+		FROM user_metrics_history h
+		INNER JOIN %[1]s s ON s.target_user_id = h.user_id
+		WHERE s.source_user_id = ?
+		  AND s.fetched_at = (SELECT MAX(fetched_at) FROM %[1]s WHERE source_user_id = ?)
+		ORDER BY h.user_id, h.recorded_at ASC, h.id ASC
+	`, table), sourceUserId, sourceUserId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string][]MetricPoint)
+	for rows.Next() {
+		var id string
+		var p MetricPoint
+		if err := rows.Scan(&id, &p.At, &p.Followers, &p.Following, &p.Tweets, &p.Listed); err != nil {
+			continue
+		}
+		history[id] = append(history[id], p)
+	}
+	return history, nil
+}
+
+// --- Snapshot diffs ---
+
+func isSnapshotTable(table string) bool {
+	return table == "following_snapshots" || table == "followers_snapshots"
+}
+
+// SnapshotInfo identifies one stored snapshot by its fetched_at timestamp.
+type SnapshotInfo struct {
+	FetchedAt string `json:"fetched_at"`
+	Count     int    `json:"count"`
+}
+
+// GetSnapshotTimestamps lists the snapshots of a source user, newest first.
+func GetSnapshotTimestamps(db *sql.DB, table, sourceUserId string) ([]SnapshotInfo, error) {
+	if !isSnapshotTable(table) {
+		return nil, fmt.Errorf("unknown snapshot table %q", table)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT fetched_at, COUNT(*) FROM %s
+		WHERE source_user_id = ?
+		GROUP BY fetched_at
+		ORDER BY fetched_at DESC
+	`, table), sourceUserId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []SnapshotInfo
+	for rows.Next() {
+		var si SnapshotInfo
+		if err := rows.Scan(&si.FetchedAt, &si.Count); err != nil {
+			continue
+		}
+		snapshots = append(snapshots, si)
+	}
+	return snapshots, nil
+}
+
+// GetSnapshotDiff returns the target IDs present at toAt but not at fromAt
+// (gained) and present at fromAt but not at toAt (lost).
+func GetSnapshotDiff(db *sql.DB, table, sourceUserId, fromAt, toAt string) (gained, lost []string, err error) {
+	if !isSnapshotTable(table) {
+		return nil, nil, fmt.Errorf("unknown snapshot table %q", table)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT target_user_id FROM %[1]s WHERE source_user_id = ? AND fetched_at = ?
+		EXCEPT
+		SELECT target_user_id FROM %[1]s WHERE source_user_id = ? AND fetched_at = ?
+	`, table)
+
+	gained, err = queryStrings(db, query, sourceUserId, toAt, sourceUserId, fromAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	lost, err = queryStrings(db, query, sourceUserId, fromAt, sourceUserId, toAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gained, lost, nil
+}
+
+// GetTargetSnapshotTimes returns the fetched_at values of the snapshots that contain targetUserId.
+func GetTargetSnapshotTimes(db *sql.DB, table, sourceUserId, targetUserId string) (map[string]bool, error) {
+	if !isSnapshotTable(table) {
+		return nil, fmt.Errorf("unknown snapshot table %q", table)
+	}
+
+	times, err := queryStrings(db, fmt.Sprintf(`
+		SELECT DISTINCT fetched_at FROM %s WHERE source_user_id = ? AND target_user_id = ?
+	`, table), sourceUserId, targetUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool, len(times))
+	for _, t := range times {
+		present[t] = true
+	}
+	return present, nil
+}
+
+// GetSnapshotPresence maps every target ever seen for sourceUserId to the set
+// of snapshot fetched_at values it appears in.
+func GetSnapshotPresence(db *sql.DB, table, sourceUserId string) (map[string]map[string]bool, error) {
+	if !isSnapshotTable(table) {
+		return nil, fmt.Errorf("unknown snapshot table %q", table)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT target_user_id, fetched_at FROM %s WHERE source_user_id = ?
+	`, table), sourceUserId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presence := make(map[string]map[string]bool)
+	for rows.Next() {
+		var target, at string
+		if err := rows.Scan(&target, &at); err != nil {
+			continue
+		}
+		if presence[target] == nil {
+			presence[target] = make(map[string]bool)
+		}
+		presence[target][at] = true
+	}
+	return presence, nil
+}
+
+// GetLatestSnapshotIDs returns the target IDs of the newest snapshot of sourceUserId.
+func GetLatestSnapshotIDs(db *sql.DB, table, sourceUserId string) ([]string, error) {
+	if !isSnapshotTable(table) {
+		return nil, fmt.Errorf("unknown snapshot table %q", table)
+	}
+	return queryStrings(db, fmt.Sprintf(`
+		SELECT target_user_id FROM %[1]s
+		WHERE source_user_id = ?
+		  AND fetched_at = (SELECT MAX(fetched_at) FROM %[1]s WHERE source_user_id = ?)
+	`, table), sourceUserId, sourceUserId)
+}
+
+func queryStrings(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			continue
+		}
+		result = append(result, v)
+	}
+	return result, nil
 }
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Credential handling of an export; accounts.bearer_token is the only secret
+// in the exported tables (OAuth tokens are never exported).
+const (
+	credentialsRedact  = "redact"
+	credentialsExclude = "exclude"
+	credentialsInclude = "include"
+)
+
+// ExportOptions scopes an export. The zero value exports every table of all
+// accounts with bearer tokens redacted.
+type ExportOptions struct {
+	// AccountUserID limits rows to one account: its snapshots, lists, fetch
+	// logs and the users they reference.
+	AccountUserID string `json:"account_user_id"`
+	// From and To bound snapshot fetched_at (RFC3339 or YYYY-MM-DD, To inclusive).
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Tables to export, default all of exportTableNames.
+	Tables      []string `json:"tables"`
+	Credentials string   `json:"credentials"`
+	// Path to write to (ExportToFile asks when empty); Gzip compresses it.
+	Path string `json:"path"`
+	Gzip bool   `json:"gzip"`
+}
+
+// ExportScope records the row filter of a scoped export, as given in
+// ExportOptions, so a replacing import only clears what the export covers.
+type ExportScope struct {
+	AccountUserID string `json:"account_user_id,omitempty"`
+	From          string `json:"from,omitempty"`
+	To            string `json:"to,omitempty"`
+}
+
+func (s *ExportScope) options() ExportOptions {
+	if s == nil {
+		return ExportOptions{}
+	}
+	return ExportOptions{AccountUserID: s.AccountUserID, From: s.From, To: s.To}
+}
+
+// ExportSummary reports a written export.
+type ExportSummary struct {
+	Path   string         `json:"path"`
+	Bytes  int64          `json:"bytes"`
+	Gzip   bool           `json:"gzip"`
+	Counts map[string]int `json:"counts"`
+}
+
+type exportTable struct {
+	name  string
+	query string
+	dest  func(p *ExportPayload) *[]map[string]interface{}
+}
+
+var exportTables = []exportTable{
+	{"users", `SELECT id, username, COALESCE(name,'') as name, COALESCE(description,'') as description,
+			COALESCE(followers_count,0) as followers_count, COALESCE(following_count,0) as following_count,
+			COALESCE(tweet_count,0) as tweet_count, COALESCE(listed_count,0) as listed_count,
+			COALESCE(verified,0) as verified, COALESCE(verified_type,'') as verified_type,
+			COALESCE(profile_image_url,'') as profile_image_url, COALESCE(location,'') as location,
+			COALESCE(created_at,'') as created_at, COALESCE(updated_at,'') as updated_at FROM users`,
+		func(p *ExportPayload) *[]map[string]interface{} { return &p.Users }},
+	{"following_snapshots", `SELECT id, source_user_id, target_user_id, fetched_at FROM following_snapshots`,
+		func(p *ExportPayload) *[]map[string]interface{} { return &p.FollowingSnapshots }},
+	{"followers_snapshots", `SELECT id, source_user_id, target_user_id, fetched_at FROM followers_snapshots`,
+		func(p *ExportPayload) *[]map[string]interface{} { return &p.FollowersSnapshots }},
+	{"accounts", `SELECT id, user_id, username, bearer_token, is_active, created_at FROM accounts`,
+		func(p *ExportPayload) *[]map[string]interface{} { return &p.Accounts }},
+	{"list_cache", `SELECT list_id, owner_user_id, name, description, member_count, private, fetched_at FROM list_cache`,
+		func(p *ExportPayload) *[]map[string]interface{} { return &p.ListCache }},
+	{"list_member_cache", `SELECT list_id, user_id, fetched_at FROM list_member_cache`,
+		func(p *ExportPayload) *[]map[string]interface{} { return &p.ListMemberCache }},
+	{"fetch_logs", `SELECT id, endpoint, user_id, status_code, created_at FROM fetch_logs`,
+		func(p *ExportPayload) *[]map[string]interface{} { return &p.FetchLogs }},
+}
+
+func exportTableNames() []string {
+	names := make([]string, len(exportTables))
+	for i, t := range exportTables {
+		names[i] = t.name
+	}
+	return names
+}
+
+// parseExportBound parses a From/To bound; a bare date as To covers the whole day.
+func parseExportBound(s string, upper bool) (string, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if upper {
+			t = t.Add(time.Second)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC3339", s)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// exportFilter is the WHERE clause of each table for opts.
+type exportFilter struct {
+	where map[string]string
+	args  map[string][]interface{}
+}
+
+func newExportFilter(opts ExportOptions) (exportFilter, error) {
+	f := exportFilter{where: make(map[string]string), args: make(map[string][]interface{})}
+
+	var snapConds []string
+	var snapArgs []interface{}
+	if opts.AccountUserID != "" {
+		snapConds = append(snapConds, "source_user_id = ?")
+		snapArgs = append(snapArgs, opts.AccountUserID)
+	}
+	for _, bound := range []struct {
+		value string
+		upper bool
+		cond  string
+	}{{opts.From, false, "fetched_at >= ?"}, {opts.To, true, "fetched_at < ?"}} {
+		if bound.value == "" {
+			continue
+		}
+		v, err := parseExportBound(bound.value, bound.upper)
+		if err != nil {
+			return f, err
+		}
+		snapConds = append(snapConds, bound.cond)
+		snapArgs = append(snapArgs, v)
+	}
+	if len(snapConds) == 0 {
+		return f, nil
+	}
+	snapWhere := strings.Join(snapConds, " AND ")
+	for _, table := range []string{"following_snapshots", "followers_snapshots"} {
+		f.where[table], f.args[table] = snapWhere, snapArgs
+	}
+
+	// Scoped users are the ones the exported snapshots and lists reference.
+	usersWhere := `id IN (SELECT target_user_id FROM following_snapshots WHERE ` + snapWhere + `)
+		OR id IN (SELECT target_user_id FROM followers_snapshots WHERE ` + snapWhere + `)`
+	usersArgs := append(append([]interface{}{}, snapArgs...), snapArgs...)
+
+	if opts.AccountUserID != "" {
+		acct := opts.AccountUserID
+		ownedLists := `list_id IN (SELECT list_id FROM list_cache WHERE owner_user_id = ?)`
+		f.where["accounts"], f.args["accounts"] = "user_id = ?", []interface{}{acct}
+		f.where["list_cache"], f.args["list_cache"] = "owner_user_id = ?", []interface{}{acct}
+		f.where["list_member_cache"], f.args["list_member_cache"] = ownedLists, []interface{}{acct}
+		f.where["fetch_logs"], f.args["fetch_logs"] = "user_id = ?", []interface{}{acct}
+
+		usersWhere += ` OR id = ? OR id IN (SELECT user_id FROM list_member_cache WHERE ` + ownedLists + `)`
+		usersArgs = append(usersArgs, acct, acct)
+	} else {
+		usersWhere += ` OR id IN (SELECT user_id FROM accounts) OR id IN (SELECT user_id FROM list_member_cache)`
+	}
+	f.where["users"], f.args["users"] = usersWhere, usersArgs
+	return f, nil
+}
+
+// BuildExport reads the tables selected by opts into a payload.
+func BuildExport(db *sql.DB, opts ExportOptions) (*ExportPayload, error) {
+	switch opts.Credentials {
+	case "":
+		opts.Credentials = credentialsRedact
+	case credentialsRedact, credentialsExclude, credentialsInclude:
+	default:
+		return nil, fmt.Errorf("credentials must be %s, %s or %s", credentialsRedact, credentialsExclude, credentialsInclude)
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range opts.Tables {
+		selected[strings.TrimSpace(name)] = true
+	}
+	for name := range selected {
+		known := false
+		for _, t := range exportTables {
+			known = known || t.name == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown table %q, expected one of %s", name, strings.Join(exportTableNames(), ", "))
+		}
+	}
+
+	filter, err := newExportFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &ExportPayload{ExportedAt: time.Now().UTC().Format(time.RFC3339)}
+	payload.SchemaVersion, _ = schemaVersion(db)
+	if opts.AccountUserID != "" || opts.From != "" || opts.To != "" {
+		payload.Scope = &ExportScope{AccountUserID: opts.AccountUserID, From: opts.From, To: opts.To}
+	}
+
+	for _, t := range exportTables {
+		if len(selected) > 0 && !selected[t.name] {
+			continue
+		}
+		query := t.query
+		if where, ok := filter.where[t.name]; ok {
+			query += " WHERE " + where
+		}
+		rows, err := queryExportRows(db, query, filter.args[t.name]...)
+		if err != nil {
+			return nil, fmt.Errorf("exporting %s: %w", t.name, err)
+		}
+		if t.name == "accounts" {
+			for _, row := range rows {
+				switch opts.Credentials {
+				case credentialsRedact:
+					row["bearer_token"] = redactedValue
+				case credentialsExclude:
+					delete(row, "bearer_token")
+				}
+			}
+		}
+		if rows == nil {
+			rows = []map[string]interface{}{}
+		}
+		*t.dest(payload) = rows
+	}
+	return payload, nil
+}
+
+func queryExportRows(db *sql.DB, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	defer rows.Close()
+
+	cols, _ := rows.Columns()
+	var result []map[string]interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scanning: %w", err)
+		}
+		row := make(map[string]interface{})
+		for i, col := range cols {
+			v := vals[i]
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			row[col] = v
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// WriteExport writes payload to path, gzipped when gz is set, and returns
+// the file size.
+func WriteExport(payload *ExportPayload, path string, gz bool) (int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("creating export file: %w", err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if gz {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(payload); err != nil {
+		return 0, fmt.Errorf("writing export: %w", err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return 0, fmt.Errorf("writing export: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("writing export: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func exportCounts(p *ExportPayload) map[string]int {
+	counts := make(map[string]int)
+	for _, t := range exportTables {
+		if rows := *t.dest(p); rows != nil {
+			counts[t.name] = len(rows)
+		}
+	}
+	return counts
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fixture identity of the account the fake API is built around. Point the
+// app at the fake with X_API_BASE_URL and add @fakeme with any bearer token.
+const (
+	fakeAccountID       = "1000"
+	fakeAccountUsername = "fakeme"
+
+	fakeUserCount      = 300
+	fakeTweetsPerUser  = 30
+	fakeDefaultPage    = 100
+	fakeMaxPage        = 1000
+	fakeRateLimitReset = 2 * time.Second
+)
+
+// fakeEpoch anchors every fixture timestamp so runs are reproducible.
+var fakeEpoch = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeUser struct {
+	ID, Username, Name, Description string
+	Followers, Following            int
+	Tweets, Listed                  int
+	Verified, Protected             bool
+	CreatedAt                       time.Time
+}
+
+type fakeList struct {
+	ID, Name, Description string
+	Private               bool
+}
+
+type fakeTweet struct {
+	ID, Text                        string
+	CreatedAt                       time.Time
+	Likes, Retweets, Replies, Quote int
+}
+
+// FakeXAPI is an in-memory X API v2 serving deterministic fixtures for the
+// endpoints the gen client uses, with cursor pagination, optional per-endpoint
+// 429s and injectable error responses. It runs via httptest (Start) or the
+// `fakeapi` CLI command.
+type FakeXAPI struct {
+	mu sync.Mutex
+
+	users       map[string]*fakeUser
+	byUsername  map[string]string
+	following   map[string][]string
+	followers   map[string][]string
+	ownedLists  map[string][]fakeList
+	listMembers map[string][]string
+	tweets      map[string][]fakeTweet
+
+	// RateLimit is the requests allowed per endpoint every fakeRateLimitReset;
+	// 0 never answers 429.
+	RateLimit int
+	windows   map[string]*fakeWindow
+
+	failures []int
+	requests int
+}
+
+type fakeWindow struct {
+	used  int
+	reset time.Time
+}
+
+func NewFakeXAPI() *FakeXAPI {
+	f := &FakeXAPI{
+		users:       make(map[string]*fakeUser),
+		byUsername:  make(map[string]string),
+		following:   make(map[string][]string),
+		followers:   make(map[string][]string),
+		ownedLists:  make(map[string][]fakeList),
+		listMembers: make(map[string][]string),
+		tweets:      make(map[string][]fakeTweet),
+		windows:     make(map[string]*fakeWindow),
+	}
+	f.loadFixtures()
+	return f
+}
+
+func fakeUserID(i int) string {
+	return strconv.Itoa(2000 + (i+fakeUserCount)%fakeUserCount)
+}
+
+// loadFixtures builds the account, 300 users and their relations. The account
+// follows users 0-219 and is followed by 100-279 (120 mutuals); user i follows
+// the next 60 users and is followed by the previous 40.
+func (f *FakeXAPI) loadFixtures() {
+	f.addUser(&fakeUser{
+		ID: fakeAccountID, Username: fakeAccountUsername, Name: "Fake Me",
+		Description: "Fixture account of the fake X API",
+		Followers:   180, Following: 220, Tweets: fakeTweetsPerUser, Listed: 3,
+		CreatedAt: fakeEpoch.AddDate(-5, 0, 0),
+	})
+	for i := 0; i < fakeUserCount; i++ {
+		f.addUser(&fakeUser{
+			ID:          fakeUserID(i),
+			Username:    fmt.Sprintf("fake_user_%03d", i),
+			Name:        fmt.Sprintf("Fake User %d", i),
+			Description: fmt.Sprintf("Fixture user %d", i),
+			Followers:   40 + (i*37)%5000,
+			Following:   60,
+			Tweets:      fakeTweetsPerUser + (i*11)%3000,
+			Listed:      i % 40,
+			Verified:    i%10 == 0,
+			Protected:   i%25 == 7,
+			CreatedAt:   fakeEpoch.AddDate(0, -i, 0),
+		})
+	}
+
+	for i := 0; i < 220; i++ {
+		f.following[fakeAccountID] = append(f.following[fakeAccountID], fakeUserID(i))
+	}
+	for i := 100; i < 280; i++ {
+		f.followers[fakeAccountID] = append(f.followers[fakeAccountID], fakeUserID(i))
+	}
+	for i := 0; i < fakeUserCount; i++ {
+		id := fakeUserID(i)
+		for k := 1; k <= 60; k++ {
+			f.following[id] = append(f.following[id], fakeUserID(i+k))
+		}
+		for k := 1; k <= 40; k++ {
+			f.followers[id] = append(f.followers[id], fakeUserID(i-k))
+		}
+	}
+
+	f.ownedLists[fakeAccountID] = []fakeList{
+		{ID: "3000", Name: "Go devs", Description: "Fixture list"},
+		{ID: "3001", Name: "Friends", Description: "Private fixture list", Private: true},
+		{ID: "3002", Name: "Empty", Description: "List without members"},
+	}
+	for i := 0; i < 150; i++ {
+		f.listMembers["3000"] = append(f.listMembers["3000"], fakeUserID(i*2))
+	}
+	for i := 200; i < 240; i++ {
+		f.listMembers["3001"] = append(f.listMembers["3001"], fakeUserID(i))
+	}
+
+	for id := range f.users {
+		n, _ := strconv.Atoi(id)
+		var tweets []fakeTweet
+		for t := 0; t < fakeTweetsPerUser; t++ {
+			tweets = append(tweets, fakeTweet{
+				ID:        strconv.Itoa(n*1000 + fakeTweetsPerUser - t),
+				Text:      fmt.Sprintf("Fixture post %d of %s", t, id),
+				CreatedAt: fakeEpoch.Add(-time.Duration(t*(1+n%5)) * 24 * time.Hour),
+				Likes:     (n*7 + t*13) % 200,
+				Retweets:  (n + t*3) % 40,
+				Replies:   (n*3 + t) % 25,
+				Quote:     t % 4,
+			})
+		}
+		f.tweets[id] = tweets
+	}
+}
+
+func (f *FakeXAPI) addUser(u *fakeUser) {
+	f.users[u.ID] = u
+	f.byUsername[strings.ToLower(u.Username)] = u.ID
+}
+
+// FailNext answers the next requests with these statuses, one each, using
+// the X problem payload (and rate-limit headers for 429).
+func (f *FakeXAPI) FailNext(statuses ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, statuses...)
+}
+
+// Requests returns how many requests reached the fake.
+func (f *FakeXAPI) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+// Start serves the fake on a random local port; Close the server when done.
+func (f *FakeXAPI) Start() *httptest.Server {
+	return httptest.NewServer(f.Handler())
+}
+
+func (f *FakeXAPI) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /2/users/by/username/{username}", f.handleUserByUsername)
+	mux.HandleFunc("GET /2/users/{id}/following", f.handleUserPage(f.following))
+	mux.HandleFunc("GET /2/users/{id}/followers", f.handleUserPage(f.followers))
+	mux.HandleFunc("POST /2/users/{id}/following", f.handleFollow)
+	mux.HandleFunc("GET /2/users/{id}/owned_lists", f.handleOwnedLists)
+	mux.HandleFunc("GET /2/lists/{id}/members", f.handleUserPage(f.listMembers))
+	mux.HandleFunc("GET /2/users/{id}/tweets", f.handleTweets)
+	mux.HandleFunc("POST /2/oauth2/token", f.handleToken)
+	return f.middleware(mux)
+}
+
+// middleware counts requests, requires a bearer or OAuth header and applies
+// injected failures and the rate limit before routing.
+func (f *FakeXAPI) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests++
+		var status int
+		if len(f.failures) > 0 {
+			status, f.failures = f.failures[0], f.failures[1:]
+		}
+		f.mu.Unlock()
+
+		if r.URL.Path != "/2/oauth2/token" && r.Header.Get("Authorization") == "" {
+			writeFakeProblem(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if status == 0 {
+			status = f.observeRateLimit(w, normalizeEndpoint(r.Method, r.URL.Path))
+		}
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("x-rate-limit-limit", strconv.Itoa(max(f.RateLimit, 1)))
+				w.Header().Set("x-rate-limit-remaining", "0")
+				w.Header().Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(fakeRateLimitReset).Unix(), 10))
+			}
+			writeFakeProblem(w, status, http.StatusText(status))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// observeRateLimit sets x-rate-limit-* headers and returns 429 once the
+// endpoint's window is used up.
+func (f *FakeXAPI) observeRateLimit(w http.ResponseWriter, endpoint string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.RateLimit <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	win, ok := f.windows[endpoint]
+	if !ok || now.After(win.reset) {
+		win = &fakeWindow{reset: now.Add(fakeRateLimitReset)}
+		f.windows[endpoint] = win
+	}
+	if win.used >= f.RateLimit {
+		return http.StatusTooManyRequests
+	}
+	win.used++
+	w.Header().Set("x-rate-limit-limit", strconv.Itoa(f.RateLimit))
+	w.Header().Set("x-rate-limit-remaining", strconv.Itoa(f.RateLimit-win.used))
+	w.Header().Set("x-rate-limit-reset", strconv.FormatInt(win.reset.Unix(), 10))
+	return 0
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeFakeProblem(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"title":  http.StatusText(status),
+		"detail": detail,
+		"type":   "about:blank",
+		"status": status,
+	})
+}
+
+func (u *fakeUser) json() map[string]any {
+	return map[string]any{
+		"id":                u.ID,
+		"username":          u.Username,
+		"name":              u.Name,
+		"description":       u.Description,
+		"created_at":        u.CreatedAt.Format(time.RFC3339),
+		"verified":          u.Verified,
+		"verified_type":     map[bool]string{true: "blue", false: "none"}[u.Verified],
+		"protected":         u.Protected,
+		"profile_image_url": "",
+		"location":          "",
+		"public_metrics": map[string]int{
+			"followers_count": u.Followers,
+			"following_count": u.Following,
+			"tweet_count":     u.Tweets,
+			"listed_count":    u.Listed,
+		},
+	}
+}
+
+func (f *FakeXAPI) handleUserByUsername(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	f.mu.Lock()
+	id, ok := f.byUsername[strings.ToLower(username)]
+	var user map[string]any
+	if ok {
+		user = f.users[id].json()
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		// X answers unknown users with 200 and an errors array.
+		writeFakeJSON(w, http.StatusOK, map[string]any{"errors": []map[string]any{{
+			"title":  "Not Found Error",
+			"detail": fmt.Sprintf("Could not find user with username: [%s].", username),
+			"type":   "https://api.twitter.com/2/problems/resource-not-found",
+		}}})
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, map[string]any{"data": user})
+}
+
+// pageBounds applies max_results and pagination_token (the decimal offset
+// of the page) to a collection of n items.
+func pageBounds(r *http.Request, n int) (start, end int, next string, err error) {
+	size := fakeDefaultPage
+	if v := r.URL.Query().Get("max_results"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size < 1 || size > fakeMaxPage {
+			return 0, 0, "", fmt.Errorf("invalid max_results %q", v)
+		}
+	}
+	if v := r.URL.Query().Get("pagination_token"); v != "" {
+		if start, err = strconv.Atoi(v); err != nil || start < 0 || start > n {
+			return 0, 0, "", fmt.Errorf("invalid pagination_token %q", v)
+		}
+	}
+	end = min(start+size, n)
+	if end < n {
+		next = strconv.Itoa(end)
+	}
+	return start, end, next, nil
+}
+
+func pageMeta(count int, next string) map[string]any {
+	meta := map[string]any{"result_count": count}
+	if next != "" {
+		meta["next_token"] = next
+	}
+	return meta
+}
+
+// handleUserPage serves a paginated user collection keyed by the path id.
+func (f *FakeXAPI) handleUserPage(relation map[string][]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		ids := relation[r.PathValue("id")]
+		start, end, next, err := pageBounds(r, len(ids))
+		if err != nil {
+			writeFakeProblem(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		body := map[string]any{"meta": pageMeta(end-start, next)}
+		if end > start {
+			users := make([]map[string]any, 0, end-start)
+			for _, id := range ids[start:end] {
+				users = append(users, f.users[id].json())
+			}
+			body["data"] = users
+		}
+		writeFakeJSON(w, http.StatusOK, body)
+	}
+}
+
+func (f *FakeXAPI) handleFollow(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetUserID string `json:"target_user_id"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &req); err != nil || req.TargetUserID == "" {
+		writeFakeProblem(w, http.StatusBadRequest, "target_user_id is required")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	source := r.PathValue("id")
+	target, ok := f.users[req.TargetUserID]
+	if !ok {
+		writeFakeProblem(w, http.StatusNotFound, "target user not found")
+		return
+	}
+	if target.Protected {
+		writeFakeJSON(w, http.StatusOK, map[string]any{"data": map[string]bool{"following": false, "pending_follow": true}})
+		return
+	}
+
+	already := false
+	for _, id := range f.following[source] {
+		already = already || id == target.ID
+	}
+	if !already {
+		f.following[source] = append(f.following[source], target.ID)
+		f.followers[target.ID] = append(f.followers[target.ID], source)
+	}
+	writeFakeJSON(w, http.StatusOK, map[string]any{"data": map[string]bool{"following": true, "pending_follow": false}})
+}
+
+func (f *FakeXAPI) handleOwnedLists(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	owner := r.PathValue("id")
+	lists := f.ownedLists[owner]
+	body := map[string]any{"meta": pageMeta(len(lists), "")}
+	if len(lists) > 0 {
+		data := make([]map[string]any, 0, len(lists))
+		for _, l := range lists {
+			data = append(data, map[string]any{
+				"id":             l.ID,
+				"name":           l.Name,
+				"description":    l.Description,
+				"private":        l.Private,
+				"member_count":   len(f.listMembers[l.ID]),
+				"follower_count": 0,
+				"owner_id":       owner,
+				"created_at":     fakeEpoch.Format(time.RFC3339),
+			})
+		}
+		body["data"] = data
+	}
+	writeFakeJSON(w, http.StatusOK, body)
+}
+
+func (f *FakeXAPI) handleTweets(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tweets := f.tweets[r.PathValue("id")]
+	start, end, next, err := pageBounds(r, len(tweets))
+	if err != nil {
+		writeFakeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	meta := pageMeta(end-start, next)
+	body := map[string]any{"meta": meta}
+	if end > start {
+		data := make([]map[string]any, 0, end-start)
+		for _, t := range tweets[start:end] {
+			data = append(data, map[string]any{
+				"id":         t.ID,
+				"text":       t.Text,
+				"created_at": t.CreatedAt.Format(time.RFC3339),
+				"public_metrics": map[string]int{
+					"like_count":    t.Likes,
+					"retweet_count": t.Retweets,
+					"reply_count":   t.Replies,
+					"quote_count":   t.Quote,
+				},
+			})
+		}
+		meta["newest_id"], meta["oldest_id"] = tweets[start].ID, tweets[end-1].ID
+		body["data"] = data
+	}
+	writeFakeJSON(w, http.StatusOK, body)
+}
+
+// handleToken issues fixture OAuth2 tokens for any code or refresh token.
+func (f *FakeXAPI) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		if r.PostForm.Get("code") == "" || r.PostForm.Get("code_verifier") == "" {
+			writeFakeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "code and code_verifier are required"})
+			return
+		}
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") == "" {
+			writeFakeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	default:
+		writeFakeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	f.mu.Lock()
+	n := f.requests
+	f.mu.Unlock()
+	writeFakeJSON(w, http.StatusOK, map[string]any{
+		"token_type":    "bearer",
+		"access_token":  fmt.Sprintf("fake-access-%d", n),
+		"refresh_token": fmt.Sprintf("fake-refresh-%d", n),
+		"expires_in":    7200,
+		"scope":         oauth2Scopes,
+	})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestApp returns an App on a fresh database whose clients talk to a
+// started FakeXAPI, with the fake account added and selected. Page spacing
+// and retry backoff are shortened for the duration of the test.
+func newTestApp(t *testing.T) (*App, *FakeXAPI) {
+	t.Helper()
+
+	fake := NewFakeXAPI()
+	srv := fake.Start()
+	t.Cleanup(srv.Close)
+
+	interval, backoff := rate_limit, backoffBase
+	rate_limit, backoffBase = time.Millisecond, 10*time.Millisecond
+	SetAPIBaseURL(srv.URL)
+	t.Cleanup(func() {
+		rate_limit, backoffBase = interval, backoff
+		SetAPIBaseURL("")
+	})
+
+	db := newTestDB(t)
+	spendLedger.SetDB(db)
+	t.Cleanup(func() { spendLedger.SetDB(nil) })
+
+	// A token per test keeps the rate limits observed by other tests apart.
+	if err := AddAccount(db, fakeAccountID, fakeAccountUsername, "token-"+t.Name()); err != nil {
+		t.Fatalf("adding account: %v", err)
+	}
+	return &App{ctx: context.Background(), db: db, selectedAccountID: fakeAccountID}, fake
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testAccount(t *testing.T, a *App) Account {
+	t.Helper()
+	acct, err := GetAccountByUserID(a.db, fakeAccountID)
+	if err != nil {
+		t.Fatalf("loading account: %v", err)
+	}
+	return *acct
+}
+
+func countRows(t *testing.T, a *App, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := a.db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+// fakeGet sends an authorized GET to the fake and decodes the JSON body.
+func fakeGet(t *testing.T, baseURL, path string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer test")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var body map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	return res, body
+}
+
+func TestFakeAPIPaginatesByOffset(t *testing.T) {
+	srv := NewFakeXAPI().Start()
+	defer srv.Close()
+
+	_, body := fakeGet(t, srv.URL, "/2/users/"+fakeAccountID+"/following?max_results=50&pagination_token=200")
+	data, _ := body["data"].([]any)
+	meta, _ := body["meta"].(map[string]any)
+	if len(data) != 20 {
+		t.Errorf("last page has %d users, want 20", len(data))
+	}
+	if _, ok := meta["next_token"]; ok {
+		t.Errorf("last page has next_token %v", meta["next_token"])
+	}
+
+	_, body = fakeGet(t, srv.URL, "/2/users/"+fakeAccountID+"/followers?max_results=50")
+	if meta, _ := body["meta"].(map[string]any); meta["next_token"] != "50" {
+		t.Errorf("first page next_token = %v, want 50", meta["next_token"])
+	}
+}
+
+func TestFakeAPIRequiresAuthorization(t *testing.T) {
+	srv := NewFakeXAPI().Start()
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/2/users/" + fakeAccountID + "/following")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", res.StatusCode)
+	}
+}
+
+func TestFakeAPIFailuresAndRateLimit(t *testing.T) {
+	fake := NewFakeXAPI()
+	fake.RateLimit = 1
+	srv := fake.Start()
+	defer srv.Close()
+
+	fake.FailNext(http.StatusServiceUnavailable)
+	path := "/2/users/by/username/" + fakeAccountUsername
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusTooManyRequests} {
+		res, _ := fakeGet(t, srv.URL, path)
+		if res.StatusCode != want {
+			t.Errorf("request %d: status %d, want %d", i, res.StatusCode, want)
+		}
+		if want == http.StatusTooManyRequests && res.Header.Get("x-rate-limit-reset") == "" {
+			t.Error("429 without x-rate-limit-reset")
+		}
+	}
+	if got := fake.Requests(); got != 3 {
+		t.Errorf("Requests() = %d, want 3", got)
+	}
+}
+
+func TestFakeAPIUnknownUserIsErrorsArray(t *testing.T) {
+	srv := NewFakeXAPI().Start()
+	defer srv.Close()
+
+	res, body := fakeGet(t, srv.URL, "/2/users/by/username/nobody_here")
+	if res.StatusCode != http.StatusOK || body["errors"] == nil || body["data"] != nil {
+		t.Errorf("status %d body %v, want 200 with errors only", res.StatusCode, body)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math/rand"
+	"time"
+)
+
+const (
+	// README: follow the top 5-10 candidates per day.
+	defaultDailyFollows = 8
+
+	followQueueInterval = time.Minute
+	minFollowGap        = 5 * time.Minute
+)
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// nextFollowAt spreads the remaining follows of the day over what is left of
+// it, jittered ±50% so they don't land at fixed intervals. With nothing
+// remaining it picks a random time in the first hour of tomorrow.
+func nextFollowAt(now time.Time, remaining int) time.Time {
+	tomorrow := startOfDay(now).AddDate(0, 0, 1)
+	if remaining <= 0 {
+		return tomorrow.Add(time.Duration(rand.Int63n(int64(time.Hour))))
+	}
+	gap := tomorrow.Sub(now) / time.Duration(remaining)
+	gap = gap/2 + time.Duration(rand.Int63n(int64(gap)+1))
+	return now.Add(max(gap, minFollowGap))
+}
+
+// runFollowQueue processes the follow queue until ctx is done. Queue state
+// lives in SQLite, so a restart picks up where it left off.
+func (a *App) runFollowQueue(ctx context.Context) {
+	ticker := time.NewTicker(followQueueInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.processFollowQueue(time.Now())
+		}
+	}
+}
+
+func (a *App) processFollowQueue(now time.Time) {
+	accounts, err := GetQueuedFollowAccounts(a.db)
+	if err != nil {
+		log.Printf("[queue] Error listing queued accounts: %v", err)
+		return
+	}
+	for _, accountUserId := range accounts {
+		a.processAccountQueue(accountUserId, now)
+	}
+}
+
+// processAccountQueue sends at most one follow for the account, once its
+// schedule is due and today's cap isn't reached.
+func (a *App) processAccountQueue(accountUserId string, now time.Time) {
+	sched := GetFollowSchedule(a.db, accountUserId)
+	if sched.NextAt == "" {
+		// Never follow the moment the first candidate is queued.
+		SetFollowNextAt(a.db, accountUserId, now.Add(time.Duration(rand.Int63n(int64(15*time.Minute)))))
+		return
+	}
+	if next, err := time.Parse(time.RFC3339, sched.NextAt); err == nil && now.Before(next) {
+		return
+	}
+
+	today := CountFollowsSince(a.db, accountUserId, startOfDay(now).UTC().Format(time.RFC3339))
+	if today >= sched.DailyLimit {
+		SetFollowNextAt(a.db, accountUserId, nextFollowAt(now, 0))
+		return
+	}
+
+	acct, err := GetAccountByUserID(a.db, accountUserId)
+	if err != nil {
+		return
+	}
+	following, err := a.followingSet(accountUserId)
+	if err != nil {
+		log.Printf("[queue] Error loading following for @%s: %v", acct.Username, err)
+		return
+	}
+
+	for {
+		item, err := NextQueuedFollow(a.db, accountUserId)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("[queue] Error reading queue for @%s: %v", acct.Username, err)
+			}
+			return
+		}
+
+		if following[item.TargetUserID] || HasFollowed(a.db, accountUserId, item.TargetUserID) {
+			// A failed update would hand the same item back forever.
+			if err := UpdateFollowQueueItem(a.db, item.ID, queueStatusSkipped, "already following"); err != nil {
+				log.Printf("[queue] Error skipping %s for @%s: %v", item.TargetUserID, acct.Username, err)
+				return
+			}
+			continue
+		}
+
+		action, err := a.followAs(acct, item.TargetUserID)
+		status, msg := queueStatusDone, ""
+		switch {
+		case action.Status == "":
+			// Nothing was sent (e.g. no OAuth1 credentials); keep it queued.
+			status = queueStatusQueued
+			if err != nil {
+				msg = err.Error()
+			}
+		case err != nil:
+			status, msg = queueStatusFailed, action.Error
+		default:
+			today++
+		}
+		if err := UpdateFollowQueueItem(a.db, item.ID, status, msg); err != nil {
+			log.Printf("[queue] Error marking %s %s for @%s: %v", item.TargetUserID, status, acct.Username, err)
+		}
+		SetFollowNextAt(a.db, accountUserId, nextFollowAt(now, sched.DailyLimit-today))
+		return
+	}
+}
+
+// followingSet returns the targets of the account's latest following snapshot.
+func (a *App) followingSet(accountUserId string) (map[string]bool, error) {
+	ids, err := GetLatestSnapshotIDs(a.db, "following_snapshots", accountUserId)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
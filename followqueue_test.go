@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNextFollowAt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tomorrow := startOfDay(now).AddDate(0, 0, 1)
+
+	for i := 0; i < 100; i++ {
+		if at := nextFollowAt(now, 0); at.Before(tomorrow) || !at.Before(tomorrow.Add(time.Hour)) {
+			t.Fatalf("nothing remaining: %s, want within the first hour of tomorrow", at)
+		}
+		// 4 left in 12 hours: gaps of 3h jittered ±50%.
+		if at := nextFollowAt(now, 4); at.Before(now.Add(90*time.Minute)) || at.After(now.Add(270*time.Minute)) {
+			t.Fatalf("4 remaining: %s, want 1.5-4.5h from now", at)
+		}
+	}
+	if at := nextFollowAt(tomorrow.Add(-time.Minute), 10); at.Sub(tomorrow.Add(-time.Minute)) != minFollowGap {
+		t.Errorf("gap %s, want at least %s", at.Sub(tomorrow.Add(-time.Minute)), minFollowGap)
+	}
+}
+
+// queueStatuses maps target to status of the account's queue items.
+func queueStatuses(t *testing.T, a *App) map[string]string {
+	t.Helper()
+	items, err := GetFollowQueue(a.db, fakeAccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]string)
+	for _, item := range items {
+		statuses[item.TargetUserID] = item.Status
+	}
+	return statuses
+}
+
+func TestFollowQueueFollowsOncePerSlotUpToDailyLimit(t *testing.T) {
+	a, fake := newTestApp(t)
+	// Follows go out with the account's OAuth2 user token.
+	userTokens.Configure(a.db, OAuth2Config{})
+	t.Cleanup(func() { userTokens.Configure(nil, OAuth2Config{}) })
+	if err := SaveOAuth2Token(a.db, fakeAccountID, &OAuth2Token{AccessToken: "user-token", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.fetchFollowingForAccount(context.Background(), testAccount(t, a)); err != nil {
+		t.Fatal(err)
+	}
+
+	followed, fresh, later := fakeUserID(0), fakeUserID(250), fakeUserID(251)
+	for _, target := range []string{followed, fresh, later} {
+		if _, err := EnqueueFollow(a.db, fakeAccountID, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetFollowDailyLimit(a.db, fakeAccountID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first pass only schedules.
+	now := time.Now()
+	a.processAccountQueue(fakeAccountID, now)
+	if got := queueStatuses(t, a); got[followed] != queueStatusQueued || got[fresh] != queueStatusQueued {
+		t.Fatalf("first pass changed the queue: %v", got)
+	}
+
+	SetFollowNextAt(a.db, fakeAccountID, now.Add(-time.Minute))
+	a.processAccountQueue(fakeAccountID, now)
+	got := queueStatuses(t, a)
+	if got[followed] != queueStatusSkipped || got[fresh] != queueStatusDone || got[later] != queueStatusQueued {
+		t.Fatalf("after a due pass: %v, want already-followed skipped and one follow sent", got)
+	}
+	fake.mu.Lock()
+	followers := fake.followers[fresh]
+	fake.mu.Unlock()
+	if len(followers) == 0 || followers[len(followers)-1] != fakeAccountID {
+		t.Errorf("fake did not record the follow of %s", fresh)
+	}
+
+	// The daily limit of 1 is used up.
+	SetFollowNextAt(a.db, fakeAccountID, now.Add(-time.Minute))
+	a.processAccountQueue(fakeAccountID, now)
+	if got := queueStatuses(t, a); got[later] != queueStatusQueued {
+		t.Errorf("follow sent past the daily limit: %v", got)
+	}
+	if next := GetFollowSchedule(a.db, fakeAccountID).NextAt; next < startOfDay(now).AddDate(0, 0, 1).UTC().Format(time.RFC3339) {
+		t.Errorf("next follow at %s, want tomorrow", next)
+	}
+}
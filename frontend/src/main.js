@@ -91,7 +91,13 @@ async function loadAccountList() {
         list.innerHTML = accounts.map(a => `
             <div class="account-item">
                 <span>@${escapeHtml(a.username)}</span>
-                <button onclick="removeAccount('${a.user_id}')" class="remove-btn">Remove</button>
+                <span class="account-actions">
+                    ${a.has_user_token
+                        ? '<span class="login-status">Logged in</span>'
+                        : `<button onclick="startLogin('${a.user_id}')" class="login-btn">PIN login</button>
+                           <button onclick="startOAuth2Login('${a.user_id}')" class="login-btn">OAuth2 login</button>`}
+                    <button onclick="removeAccount('${a.user_id}')" class="remove-btn">Remove</button>
+                </span>
             </div>
         `).join('');
     } catch (err) {
@@ -99,6 +105,57 @@ async function loadAccountList() {
     }
 }
 
+// OAuth1 PIN login: the user authorizes in the browser and pastes the PIN.
+async function startLogin(userID) {
+    try {
+        const url = await window.go.main.App.StartOAuth1Login(userID);
+        document.getElementById('oauth1-url').href = url;
+        document.getElementById('oauth1-pin').value = '';
+        document.getElementById('oauth1-login').style.display = '';
+        openExternal(url);
+    } catch (err) {
+        alert('Error starting login: ' + err);
+    }
+}
+
+function openExternal(url) {
+    if (window.runtime) {
+        window.runtime.BrowserOpenURL(url);
+    } else {
+        window.open(url, '_blank');
+    }
+}
+
+// OAuth2 PKCE login: X redirects back to a loopback server in the app.
+async function startOAuth2Login(userID) {
+    try {
+        const url = await window.go.main.App.StartOAuth2Login(userID);
+        openExternal(url);
+        await window.go.main.App.WaitOAuth2Login();
+        await loadAccountList();
+    } catch (err) {
+        alert('OAuth2 login failed: ' + err);
+    }
+}
+
+async function completeLogin() {
+    const input = document.getElementById('oauth1-pin');
+    const btn = document.getElementById('oauth1-pin-btn');
+    const pin = input.value.trim();
+    if (!pin) return;
+
+    btn.disabled = true;
+    try {
+        await window.go.main.App.CompleteOAuth1Login(pin);
+        document.getElementById('oauth1-login').style.display = 'none';
+        await loadAccountList();
+    } catch (err) {
+        alert('Login failed: ' + err);
+    } finally {
+        btn.disabled = false;
+    }
+}
+
 // --- Stats display ---
 
 function updateStatsDisplay(stats, label) {
@@ -120,6 +177,44 @@ function updateStatsDisplay(stats, label) {
     } else {
         nextEl.textContent = '';
     }
+
+    loadEstimate(label);
+}
+
+// --- Cost estimate ---
+
+let currentEstimate = null;
+
+async function loadEstimate(label) {
+    const el = document.getElementById('fetch-estimate');
+    try {
+        if (label === 'lists') {
+            currentEstimate = await window.go.main.App.EstimateFetchLists();
+        } else if (label === 'followers') {
+            currentEstimate = await window.go.main.App.EstimateFetchFollowers();
+        } else if (label === 'seeds') {
+            currentEstimate = await window.go.main.App.EstimateFetchSeeds();
+        } else {
+            currentEstimate = await window.go.main.App.EstimateFetchFollowing();
+        }
+    } catch (err) {
+        console.error('Error loading estimate:', err);
+        currentEstimate = null;
+    }
+
+    if (!currentEstimate) {
+        el.textContent = '';
+        el.title = '';
+        return;
+    }
+
+    const e = currentEstimate;
+    el.textContent = e.items > 0
+        ? (e.known ? '~' : '≥') + formatCost(e.cost)
+        : 'Cached';
+    el.title = `${e.items} items in ${e.pages} pages, ${formatCost(e.cost)}` +
+        (e.skipped_items > 0 ? `\n${e.skipped_items} items (${formatCost(e.skipped_cost)}) skipped, cache fresh` : '') +
+        (e.budget && e.budget.message ? `\n${e.budget.message}` : '');
 }
 
 // --- Data loading ---
@@ -148,7 +243,7 @@ function renderTable(users) {
     }
 
     tbody.innerHTML = users.map(u => `
-        <tr>
+        <tr class="clickable" onclick="openTimeline('${u.id}')">
             <td>${u.profile_image_url
                 ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
                 : '<div class="avatar"></div>'}</td>
@@ -237,7 +332,7 @@ function renderFollowersTable(users) {
     }
 
     tbody.innerHTML = users.map(u => `
-        <tr>
+        <tr class="clickable" onclick="openTimeline('${u.id}')">
             <td>${u.profile_image_url
                 ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
                 : '<div class="avatar"></div>'}</td>
@@ -302,25 +397,37 @@ function sortFollowersBy(field) {
 
 async function fetchNow() {
     const btn = document.getElementById('fetch-btn');
+    const e = currentEstimate;
+    if (e && e.items > 0 && !confirm(`This fetch reads ~${e.items} items in ${e.pages} pages and costs about ${formatCost(e.cost)}. Continue?`)) {
+        return;
+    }
     btn.disabled = true;
     btn.textContent = 'Fetching...';
 
     try {
         const isListsTab = document.getElementById('tab-lists').classList.contains('active');
         const isFollowersTab = document.getElementById('tab-followers').classList.contains('active');
+        const isChangesTab = document.getElementById('tab-changes').classList.contains('active');
+        const isDiscoverTab = document.getElementById('tab-discover').classList.contains('active');
+        let result;
         if (isListsTab) {
-            const result = await window.go.main.App.FetchListsNow();
-            console.log(result);
+            result = await window.go.main.App.FetchListsNow();
             await loadLists();
         } else if (isFollowersTab) {
-            const result = await window.go.main.App.FetchFollowersNow();
-            console.log(result);
+            result = await window.go.main.App.FetchFollowersNow();
             await loadFollowers();
+        } else if (isChangesTab) {
+            result = await window.go.main.App.FetchFollowersNow();
+            await loadChanges();
+        } else if (isDiscoverTab) {
+            result = await window.go.main.App.FetchSeedsNow();
+            await loadDiscover();
         } else {
-            const result = await window.go.main.App.FetchNow();
-            console.log(result);
+            result = await window.go.main.App.FetchNow();
             await loadData();
         }
+        console.log(result);
+        showFetchStatus(result);
     } catch (err) {
         console.error('Error fetching:', err);
     } finally {
@@ -329,6 +436,12 @@ async function fetchNow() {
     }
 }
 
+function showFetchStatus(message) {
+    const el = document.getElementById('fetch-status');
+    el.textContent = message || '';
+    el.classList.toggle('visible', !!message);
+}
+
 // --- Tab switching ---
 
 function switchTab(tab) {
@@ -344,6 +457,19 @@ function switchTab(tab) {
         loadFollowers();
     } else if (tab === 'following') {
         loadData();
+    } else if (tab === 'changes') {
+        loadChanges();
+    } else if (tab === 'relationships') {
+        loadRelationships();
+    } else if (tab === 'queue') {
+        loadQueue();
+    } else if (tab === 'discover') {
+        loadDiscover();
+    } else if (tab === 'growth') {
+        loadAccountGrowth();
+    } else if (tab === 'trends') {
+        loadGrowth();
+        loadDormant();
     }
 }
 
@@ -419,7 +545,7 @@ function renderListMembers(users) {
         return;
     }
     tbody.innerHTML = users.map(u => `
-        <tr>
+        <tr class="clickable" onclick="openTimeline('${u.id}')">
             <td>${u.profile_image_url
                 ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
                 : '<div class="avatar"></div>'}</td>
@@ -451,24 +577,601 @@ function filterListMembers() {
     renderListMembers(filtered);
 }
 
+// --- Relationships ---
+
+let relationshipBuckets = null;
+let currentBucket = 'mutual';
+
+async function loadRelationships() {
+    const sortBy = document.getElementById('rel-sort-by').value;
+    const sortDir = document.getElementById('rel-sort-dir').value;
+    try {
+        relationshipBuckets = await window.go.main.App.GetRelationshipBuckets(sortBy, sortDir);
+        for (const bucket of ['mutual', 'not_following_back', 'fans']) {
+            document.getElementById(`bucket-${bucket}-count`).textContent = relationshipBuckets[bucket + '_count'] || 0;
+        }
+        renderBucket();
+    } catch (err) {
+        console.error('Error loading relationships:', err);
+        document.getElementById('relationships-body').innerHTML =
+            '<tr><td colspan="8" class="loading">Error loading data</td></tr>';
+    }
+}
+
+function switchBucket(bucket) {
+    currentBucket = bucket;
+    document.querySelectorAll('.bucket-btn').forEach(b =>
+        b.classList.toggle('active', b.dataset.bucket === bucket));
+    renderBucket();
+}
+
+function renderBucket() {
+    const tbody = document.getElementById('relationships-body');
+    const users = relationshipBuckets ? relationshipBuckets[currentBucket] : null;
+
+    if (!users || users.length === 0) {
+        tbody.innerHTML = '<tr><td colspan="8" class="loading">Nobody here. Fetch both following and followers first.</td></tr>';
+        return;
+    }
+
+    tbody.innerHTML = users.map(u => `
+        <tr class="clickable" onclick="openTimeline('${u.id}')">
+            <td>${u.profile_image_url
+                ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
+                : '<div class="avatar"></div>'}</td>
+            <td>
+                <div class="user-cell">
+                    <span class="user-name">
+                        ${escapeHtml(u.name)}${u.verified ? '<span class="verified-badge">&#x2713;</span>' : ''}
+                    </span>
+                    <span class="user-handle">@${escapeHtml(u.username)}</span>
+                </div>
+            </td>
+            <td class="desc-cell" title="${escapeHtml(u.description)}">${escapeHtml(u.description)}</td>
+            <td class="num-cell">${formatNumber(u.followers_count)}</td>
+            <td class="num-cell">${formatNumber(u.following_count)}</td>
+            <td class="num-cell">${formatNumber(u.tweet_count)}</td>
+            <td class="loc-cell">${escapeHtml(u.location)}</td>
+            <td class="lists-cell">${renderListBadges(u.lists)}</td>
+        </tr>
+    `).join('');
+}
+
+// --- Changes (follower snapshot diff) ---
+
+async function loadChanges() {
+    try {
+        const stats = await window.go.main.App.GetFollowersStats();
+        updateStatsDisplay(stats, 'followers');
+
+        loadChurners();
+
+        const snapshots = await window.go.main.App.GetFollowerSnapshots() || [];
+        const fromEl = document.getElementById('diff-from');
+        const toEl = document.getElementById('diff-to');
+        const options = snapshots.map(s =>
+            `<option value="${s.fetched_at}">${new Date(s.fetched_at).toLocaleString()} (${s.count})</option>`
+        ).join('');
+        fromEl.innerHTML = options;
+        toEl.innerHTML = options;
+
+        if (snapshots.length < 2) {
+            renderDiff({ gained: [], lost: [] }, 'Need at least two follower snapshots. Fetch followers again later.');
+            return;
+        }
+        toEl.value = snapshots[0].fetched_at;
+        fromEl.value = snapshots[1].fetched_at;
+        await loadDiff();
+    } catch (err) {
+        console.error('Error loading changes:', err);
+        renderDiff({ gained: [], lost: [] }, 'Error loading changes');
+    }
+}
+
+async function loadChurners() {
+    const el = document.getElementById('diff-churners');
+    try {
+        const churners = await window.go.main.App.GetFollowerChurners() || [];
+        document.getElementById('diff-churners-count').textContent = churners.length;
+        if (churners.length === 0) {
+            el.innerHTML = '<div class="loading">No churners yet</div>';
+            return;
+        }
+        el.innerHTML = churners.map(u => `
+            <div class="diff-user clickable" onclick="openTimeline('${u.id}')">
+                ${u.profile_image_url
+                    ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
+                    : '<div class="avatar"></div>'}
+                <div class="user-cell">
+                    <span class="user-name">${escapeHtml(u.name)}</span>
+                    <span class="user-handle">@${escapeHtml(u.username)}</span>
+                </div>
+                <span class="diff-user-meta">${u.follows}&times; followed, ${u.unfollows}&times; unfollowed</span>
+            </div>
+        `).join('');
+    } catch (err) {
+        console.error('Error loading churners:', err);
+        el.innerHTML = '<div class="loading">Error loading churners</div>';
+    }
+}
+
+async function loadDiff() {
+    const from = document.getElementById('diff-from').value;
+    const to = document.getElementById('diff-to').value;
+    if (!from || !to) return;
+
+    try {
+        const diff = await window.go.main.App.GetFollowersDiff(from, to);
+        renderDiff(diff, from === to ? 'Pick two different snapshots.' : 'No changes');
+    } catch (err) {
+        console.error('Error loading diff:', err);
+        renderDiff({ gained: [], lost: [] }, 'Error loading diff');
+    }
+}
+
+function renderDiff(diff, emptyText) {
+    const gained = diff.gained || [];
+    const lost = diff.lost || [];
+    document.getElementById('diff-gained-count').textContent = gained.length;
+    document.getElementById('diff-lost-count').textContent = lost.length;
+    document.getElementById('diff-gained').innerHTML = renderDiffUsers(gained, emptyText);
+    document.getElementById('diff-lost').innerHTML = renderDiffUsers(lost, emptyText);
+}
+
+function renderDiffUsers(users, emptyText) {
+    if (users.length === 0) {
+        return `<div class="loading">${escapeHtml(emptyText)}</div>`;
+    }
+    return users.map(u => `
+        <div class="diff-user clickable" onclick="openTimeline('${u.id}')">
+            ${u.profile_image_url
+                ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
+                : '<div class="avatar"></div>'}
+            <div class="user-cell">
+                <span class="user-name">
+                    ${escapeHtml(u.name)}${u.verified ? '<span class="verified-badge">&#x2713;</span>' : ''}
+                </span>
+                <span class="user-handle">@${escapeHtml(u.username)}</span>
+            </div>
+            <span class="diff-user-meta">${formatNumber(u.followers_count)} followers</span>
+            <span class="lists-cell">${renderListBadges(u.lists)}</span>
+        </div>
+    `).join('');
+}
+
+// --- Account growth ---
+
+async function loadAccountGrowth() {
+    const chart = document.getElementById('growth-chart');
+    const summary = document.getElementById('growth-summary');
+    const range = document.getElementById('growth-range').value;
+    chart.innerHTML = '<div class="loading">Loading growth...</div>';
+    summary.textContent = '';
+    try {
+        const g = await window.go.main.App.GetAccountGrowth('', range);
+        const points = g.points || [];
+        if (points.length === 0) {
+            chart.innerHTML = '<div class="loading">No history yet. Points are added with every fetch and a daily metrics check.</div>';
+            return;
+        }
+        const net = g.net_change > 0 ? `+${formatNumber(g.net_change)}` : formatNumber(g.net_change);
+        summary.textContent = `Net ${net} followers · ${formatNumber(g.gained)} gained · ${formatNumber(g.lost)} lost`;
+        chart.innerHTML = renderGrowthChart(points);
+    } catch (err) {
+        console.error('Error loading account growth:', err);
+        chart.innerHTML = '<div class="loading">Error loading growth</div>';
+    }
+}
+
+// renderGrowthChart draws followers/following as lines scaled to their own
+// range, with gained/lost bars per day underneath.
+function renderGrowthChart(points) {
+    const w = 800, h = 240, barH = 80, pad = 40;
+    const step = points.length > 1 ? (w - 2 * pad) / (points.length - 1) : 0;
+    const x = i => pad + i * step;
+
+    // Days before a series' first value are null and left undrawn.
+    const line = (key, cls) => {
+        const known = points.map((p, i) => [i, p[key]]).filter(([, v]) => v !== null);
+        if (known.length === 0) return '';
+        const values = known.map(([, v]) => v);
+        const lo = Math.min(...values), hi = Math.max(...values);
+        const span = hi - lo || 1;
+        const y = v => h - pad - (v - lo) / span * (h - 2 * pad);
+        const coords = known.map(([i, v]) => `${x(i).toFixed(1)},${y(v).toFixed(1)}`).join(' ');
+        return `<polyline class="${cls}" points="${coords}"/>
+            <text class="axis-label" x="${w - pad + 4}" y="${y(values[values.length - 1]) + 4}">${formatNumber(values[values.length - 1])}</text>`;
+    };
+
+    const maxBar = Math.max(1, ...points.map(p => Math.max(p.gained, p.lost)));
+    const barW = Math.max(2, Math.min(12, step / 2 - 1));
+    const mid = h + barH / 2;
+    const bars = points.map((p, i) => {
+        const gh = p.gained / maxBar * (barH / 2 - 4);
+        const lh = p.lost / maxBar * (barH / 2 - 4);
+        return `<rect class="bar-gained" x="${(x(i) - barW).toFixed(1)}" y="${(mid - gh).toFixed(1)}" width="${barW.toFixed(1)}" height="${gh.toFixed(1)}"><title>${p.date}: +${p.gained}</title></rect>
+            <rect class="bar-lost" x="${x(i).toFixed(1)}" y="${mid}" width="${barW.toFixed(1)}" height="${lh.toFixed(1)}"><title>${p.date}: -${p.lost}</title></rect>`;
+    }).join('');
+
+    return `<svg viewBox="0 0 ${w} ${h + barH}" preserveAspectRatio="none">
+        ${line('following', 'line-following')}
+        ${line('followers', 'line-followers')}
+        <line class="axis" x1="${pad}" y1="${mid}" x2="${w - pad}" y2="${mid}"/>
+        ${bars}
+        <text class="axis-label" x="${pad}" y="${h + barH - 2}">${points[0].date}</text>
+        <text class="axis-label" x="${w - pad}" y="${h + barH - 2}" text-anchor="end">${points[points.length - 1].date}</text>
+    </svg>`;
+}
+
+// --- Trends ---
+
+async function loadGrowth() {
+    const el = document.getElementById('growth-list');
+    const days = parseInt(document.getElementById('growth-days').value, 10);
+    try {
+        const users = await window.go.main.App.GetFastestGrowing(days) || [];
+        document.getElementById('growth-count').textContent = users.length;
+        if (users.length === 0) {
+            el.innerHTML = '<div class="loading">No growth recorded yet. History builds up with every fetch.</div>';
+            return;
+        }
+        el.innerHTML = users.map(u => `
+            <div class="diff-user clickable" onclick="openTimeline('${u.id}')">
+                ${u.profile_image_url
+                    ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
+                    : '<div class="avatar"></div>'}
+                <div class="user-cell">
+                    <span class="user-name">${escapeHtml(u.name)}</span>
+                    <span class="user-handle">@${escapeHtml(u.username)}</span>
+                </div>
+                <span class="diff-user-meta">+${formatNumber(u.followers_delta)} (${u.growth_pct.toFixed(1)}%)</span>
+            </div>
+        `).join('');
+    } catch (err) {
+        console.error('Error loading growth:', err);
+        el.innerHTML = '<div class="loading">Error loading growth</div>';
+    }
+}
+
+async function loadDormant() {
+    const el = document.getElementById('dormant-list');
+    const days = parseInt(document.getElementById('dormant-days').value, 10);
+    try {
+        const users = await window.go.main.App.GetDormantAccounts(days) || [];
+        document.getElementById('dormant-count').textContent = users.length;
+        if (users.length === 0) {
+            el.innerHTML = '<div class="loading">No dormant accounts</div>';
+            return;
+        }
+        el.innerHTML = users.map(u => `
+            <div class="diff-user clickable" onclick="openTimeline('${u.id}')">
+                ${u.profile_image_url
+                    ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
+                    : '<div class="avatar"></div>'}
+                <div class="user-cell">
+                    <span class="user-name">${escapeHtml(u.name)}</span>
+                    <span class="user-handle">@${escapeHtml(u.username)}</span>
+                </div>
+                <span class="diff-user-meta">idle ${u.idle_days} days</span>
+            </div>
+        `).join('');
+    } catch (err) {
+        console.error('Error loading dormant accounts:', err);
+        el.innerHTML = '<div class="loading">Error loading dormant accounts</div>';
+    }
+}
+
+// --- User timeline drawer ---
+
+async function openTimeline(userId) {
+    const drawer = document.getElementById('timeline-drawer');
+    const body = document.getElementById('timeline-body');
+    body.innerHTML = '<div class="loading">Loading...</div>';
+    drawer.classList.add('visible');
+
+    try {
+        const tl = await window.go.main.App.GetUserTimeline(userId);
+        const u = tl.user;
+        const header = u ? `
+            <div class="timeline-user">
+                ${u.profile_image_url ? `<img class="avatar" src="${u.profile_image_url}" alt="">` : '<div class="avatar"></div>'}
+                <div class="user-cell">
+                    <span class="user-name">${escapeHtml(u.name)}</span>
+                    <span class="user-handle">@${escapeHtml(u.username)}</span>
+                </div>
+            </div>
+            <div class="timeline-summary">Followed you ${tl.follows}&times;, unfollowed ${tl.unfollows}&times;</div>
+            <div class="follow-action">
+                <button id="follow-btn" onclick="followUser('${u.id}', '${escapeHtml(u.username)}')">Follow</button>
+                <button class="back-btn" onclick="queueFollow('${u.id}')">Add to queue</button>
+                <span id="follow-result"></span>
+            </div>
+            <div id="content-stats" class="content-stats"></div>
+        ` : '';
+
+        const events = tl.events || [];
+        const list = events.length === 0
+            ? '<div class="loading">No snapshot history</div>'
+            : events.map(e => `
+                <div class="timeline-event ${e.type}">
+                    <span class="timeline-date">${new Date(e.at).toLocaleDateString()}</span>
+                    <span>${describeEvent(e)}</span>
+                </div>
+            `).join('');
+        body.innerHTML = header + list;
+        if (u) {
+            renderContentStats(u.id, await window.go.main.App.GetContentStats(u.id));
+        }
+    } catch (err) {
+        console.error('Error loading timeline:', err);
+        body.innerHTML = '<div class="loading">Error loading timeline</div>';
+    }
+}
+
+function describeEvent(e) {
+    const since = e.initial ? ' (already at first snapshot)' : '';
+    if (e.relation === 'follower') {
+        return (e.type === 'followed' ? 'Followed you' : 'Unfollowed you') + since;
+    }
+    return (e.type === 'followed' ? 'You followed' : 'You unfollowed') + since;
+}
+
+async function followUser(userId, username) {
+    if (!confirm(`Follow @${username} from the selected account?`)) {
+        return;
+    }
+
+    const btn = document.getElementById('follow-btn');
+    const result = document.getElementById('follow-result');
+    btn.disabled = true;
+    btn.textContent = 'Following...';
+
+    try {
+        const action = await window.go.main.App.FollowUser(userId);
+        result.textContent = action.status === 'pending' ? 'Follow request pending' : 'Followed';
+        btn.textContent = 'Followed';
+    } catch (err) {
+        console.error('Error following:', err);
+        result.textContent = String(err);
+        btn.disabled = false;
+        btn.textContent = 'Follow';
+    }
+}
+
+function renderContentStats(userId, stats) {
+    const el = document.getElementById('content-stats');
+    if (!el) return;
+    const button = `<button class="back-btn" onclick="scoreContent('${userId}')">${stats ? 'Rescore' : 'Score content'}</button>`;
+    if (!stats) {
+        el.innerHTML = `<span>No content score yet</span>${button}`;
+        return;
+    }
+    if (stats.tweets < 5) {
+        el.innerHTML = `<span>Only ${stats.tweets} recent posts, too few to score</span>${button}`;
+        return;
+    }
+    el.innerHTML = `
+        <span class="content-score">${stats.score.toFixed(0)}</span>
+        <span>stability &middot; ${stats.posts_per_week.toFixed(1)} posts/week &middot;
+            ${(stats.engagement_rate * 100).toFixed(2)}% engagement</span>
+        ${button}
+    `;
+}
+
+async function scoreContent(userId) {
+    const est = await window.go.main.App.EstimateScoreContent(userId);
+    if (!confirm(`Reads up to ${est.items} posts for about ${formatCost(est.cost)}. Continue?`)) {
+        return;
+    }
+    const el = document.getElementById('content-stats');
+    el.innerHTML = '<span>Scoring...</span>';
+    try {
+        renderContentStats(userId, await window.go.main.App.ScoreUserContent(userId));
+    } catch (err) {
+        console.error('Error scoring content:', err);
+        el.innerHTML = `<span>${escapeHtml(String(err))}</span>`;
+    }
+}
+
+async function queueFollow(userId) {
+    const result = document.getElementById('follow-result');
+    try {
+        const r = await window.go.main.App.EnqueueFollows([userId]);
+        result.textContent = r.added > 0 ? 'Queued' : 'Already following or queued';
+    } catch (err) {
+        console.error('Error queueing follow:', err);
+        result.textContent = String(err);
+    }
+}
+
+function closeTimeline() {
+    document.getElementById('timeline-drawer').classList.remove('visible');
+}
+
+// --- Discover (friends-of-friends recommendations) ---
+
+async function loadDiscover() {
+    loadEstimate('seeds');
+    await Promise.all([loadSeeds(), loadRecommendations()]);
+}
+
+async function loadSeeds() {
+    const el = document.getElementById('seed-list');
+    try {
+        const seeds = await window.go.main.App.GetSeeds();
+        if (!seeds || seeds.length === 0) {
+            el.innerHTML = '<span class="seed-hint">Add accounts whose followings you want to mine, then press Fetch Now.</span>';
+            return;
+        }
+        el.innerHTML = seeds.map(s => `
+            <span class="seed-chip ${s.fetched_at ? '' : 'unfetched'}" title="${s.fetched_at ? 'Fetched ' + new Date(s.fetched_at).toLocaleDateString() : 'Not fetched yet'}">
+                @${escapeHtml(s.username || s.user_id)}
+                <button onclick="removeSeed('${s.user_id}')">&times;</button>
+            </span>
+        `).join('');
+    } catch (err) {
+        console.error('Error loading seeds:', err);
+    }
+}
+
+async function addSeed() {
+    const input = document.getElementById('seed-username');
+    const username = input.value.trim();
+    if (!username) return;
+    try {
+        await window.go.main.App.AddSeed(username);
+        input.value = '';
+        loadDiscover();
+    } catch (err) {
+        alert(String(err));
+    }
+}
+
+async function removeSeed(userId) {
+    try {
+        await window.go.main.App.RemoveSeed(userId);
+        loadDiscover();
+    } catch (err) {
+        console.error('Error removing seed:', err);
+    }
+}
+
+async function loadRecommendations() {
+    const tbody = document.getElementById('discover-body');
+    const minSeeds = parseInt(document.getElementById('min-seeds').value, 10);
+    try {
+        const recs = await window.go.main.App.GetRecommendations(minSeeds);
+        if (!recs || recs.length === 0) {
+            tbody.innerHTML = '<tr><td colspan="10" class="loading">No recommendations yet</td></tr>';
+            return;
+        }
+
+        tbody.innerHTML = recs.map(u => `
+            <tr class="clickable" onclick="openTimeline('${u.id}')">
+                <td>${u.profile_image_url
+                    ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
+                    : '<div class="avatar"></div>'}</td>
+                <td>
+                    <div class="user-cell">
+                        <span class="user-name">
+                            ${escapeHtml(u.name)}${u.verified ? '<span class="verified-badge">&#x2713;</span>' : ''}
+                        </span>
+                        <span class="user-handle">@${escapeHtml(u.username)}</span>
+                    </div>
+                </td>
+                <td class="desc-cell" title="${escapeHtml(u.description)}">${escapeHtml(u.description)}</td>
+                <td class="num-cell">${u.seed_count}</td>
+                <td class="num-cell">${u.score.toFixed(2)}</td>
+                <td class="num-cell">${u.stability_score ? u.stability_score.toFixed(0) : '-'}</td>
+                <td class="num-cell">${formatNumber(u.followers_count)}</td>
+                <td class="num-cell">${formatNumber(u.following_count)}</td>
+                <td class="lists-cell">${renderListBadges(u.lists)}</td>
+                <td><button class="back-btn" onclick="event.stopPropagation(); queueRecommendation(this, '${u.id}')">Queue</button></td>
+            </tr>
+        `).join('');
+    } catch (err) {
+        console.error('Error loading recommendations:', err);
+        tbody.innerHTML = '<tr><td colspan="10" class="loading">Error loading data</td></tr>';
+    }
+}
+
+async function queueRecommendation(btn, userId) {
+    btn.disabled = true;
+    try {
+        const r = await window.go.main.App.EnqueueFollows([userId]);
+        btn.textContent = r.added > 0 ? 'Queued' : 'Skipped';
+    } catch (err) {
+        console.error('Error queueing follow:', err);
+        btn.disabled = false;
+    }
+}
+
+// --- Follow queue ---
+
+async function loadQueue() {
+    const tbody = document.getElementById('queue-body');
+    try {
+        const [sched, items] = await Promise.all([
+            window.go.main.App.GetFollowSchedule(),
+            window.go.main.App.GetFollowQueue(),
+        ]);
+
+        const next = sched.queued > 0 && sched.next_at
+            ? `, next around ${new Date(sched.next_at).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}`
+            : '';
+        document.getElementById('queue-summary').textContent =
+            `${sched.followed_today}/${sched.daily_limit} followed today, ${sched.queued} queued${next}`;
+        document.getElementById('queue-daily-limit').value = sched.daily_limit;
+
+        if (!items || items.length === 0) {
+            tbody.innerHTML = '<tr><td colspan="6" class="loading">Queue is empty. Open a user from any table and choose "Add to queue".</td></tr>';
+            return;
+        }
+
+        tbody.innerHTML = items.map(q => {
+            const u = q.user || { id: q.target_user_id, username: q.target_user_id, name: '' };
+            return `
+                <tr>
+                    <td>${u.profile_image_url
+                        ? `<img class="avatar" src="${u.profile_image_url}" alt="" loading="lazy">`
+                        : '<div class="avatar"></div>'}</td>
+                    <td class="clickable" onclick="openTimeline('${q.target_user_id}')">
+                        <div class="user-cell">
+                            <span class="user-name">${escapeHtml(u.name)}</span>
+                            <span class="user-handle">@${escapeHtml(u.username)}</span>
+                        </div>
+                    </td>
+                    <td class="num-cell">${formatNumber(u.followers_count)}</td>
+                    <td><span class="queue-status ${q.status}" title="${escapeHtml(q.last_error || '')}">${q.status}</span></td>
+                    <td>${new Date(q.added_at).toLocaleDateString()}</td>
+                    <td><button class="remove-btn" onclick="removeQueued(${q.id})">Remove</button></td>
+                </tr>
+            `;
+        }).join('');
+    } catch (err) {
+        console.error('Error loading queue:', err);
+        tbody.innerHTML = '<tr><td colspan="6" class="loading">Error loading queue</td></tr>';
+    }
+}
+
+async function removeQueued(id) {
+    try {
+        await window.go.main.App.RemoveQueuedFollow(id);
+        loadQueue();
+    } catch (err) {
+        console.error('Error removing queued follow:', err);
+    }
+}
+
+async function saveDailyLimit() {
+    const limit = parseInt(document.getElementById('queue-daily-limit').value, 10);
+    try {
+        await window.go.main.App.SetFollowDailyLimit(limit);
+        loadQueue();
+    } catch (err) {
+        alert(String(err));
+    }
+}
+
 // --- Export ---
 
 async function exportData() {
     const btn = document.getElementById('export-btn');
+    const includeTokens = document.getElementById('export-tokens').checked;
+    if (includeTokens && !confirm('The export will contain bearer tokens in plain text. Continue?')) return;
+
     btn.disabled = true;
     btn.textContent = 'Exporting...';
 
     try {
-        const json = await window.go.main.App.ExportData();
-        const blob = new Blob([json], { type: 'application/json' });
-        const url = URL.createObjectURL(blob);
-        const a = document.createElement('a');
-        a.href = url;
-        a.download = 'xboost-export-' + new Date().toISOString().slice(0, 10) + '.json';
-        document.body.appendChild(a);
-        a.click();
-        document.body.removeChild(a);
-        URL.revokeObjectURL(url);
+        const opts = { credentials: includeTokens ? 'include' : 'redact', gzip: true };
+        if (document.getElementById('export-account-only').checked) {
+            opts.account_user_id = await window.go.main.App.GetSelectedAccount();
+        }
+        const summary = await window.go.main.App.ExportToFile(opts);
+        if (summary.path) {
+            alert('Exported to ' + summary.path + ' (' + formatNumber(summary.bytes) + ' bytes)');
+        }
     } catch (err) {
         alert('Export failed: ' + err);
     } finally {
@@ -477,6 +1180,36 @@ async function exportData() {
     }
 }
 
+// --- Import ---
+
+async function importData(input) {
+    const file = input.files[0];
+    input.value = '';
+    if (!file) return;
+
+    const replace = document.getElementById('import-replace').checked;
+    if (replace && !confirm('Replace users, snapshots, accounts, list caches and fetch logs with ' + file.name + '?')) return;
+
+    const btn = document.getElementById('import-btn');
+    btn.disabled = true;
+    btn.textContent = 'Importing...';
+
+    try {
+        const text = file.name.endsWith('.gz')
+            ? await new Response(file.stream().pipeThrough(new DecompressionStream('gzip'))).text()
+            : await file.text();
+        const result = await window.go.main.App.ImportData(text, replace);
+        const lines = result.tables.map(t => t.table + ': ' + t.imported + ' imported, ' + t.skipped + ' skipped');
+        alert('Import complete\n\n' + lines.join('\n'));
+        loadAccounts();
+    } catch (err) {
+        alert('Import failed: ' + err);
+    } finally {
+        btn.disabled = false;
+        btn.textContent = 'Import Data';
+    }
+}
+
 // --- Utilities ---
 
 function formatNumber(n) {
@@ -485,6 +1218,10 @@ function formatNumber(n) {
     return String(n);
 }
 
+function formatCost(usd) {
+    return '$' + (usd || 0).toFixed(2);
+}
+
 function escapeHtml(text) {
     if (!text) return '';
     const div = document.createElement('div');
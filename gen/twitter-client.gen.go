@@ -311,6 +311,38 @@ const (
 	ListUserOwnedListsParamsUserFieldsWithheld        ListUserOwnedListsParamsUserFields = "withheld"
 )
 
+// Defines values for UsersIdTweetsParamsExclude.
+const (
+	Replies  UsersIdTweetsParamsExclude = "replies"
+	Retweets UsersIdTweetsParamsExclude = "retweets"
+)
+
+// Defines values for UsersIdTweetsParamsTweetFields.
+const (
+	UsersIdTweetsParamsTweetFieldsAttachments         UsersIdTweetsParamsTweetFields = "attachments"
+	UsersIdTweetsParamsTweetFieldsAuthorId            UsersIdTweetsParamsTweetFields = "author_id"
+	UsersIdTweetsParamsTweetFieldsContextAnnotations  UsersIdTweetsParamsTweetFields = "context_annotations"
+	UsersIdTweetsParamsTweetFieldsConversationId      UsersIdTweetsParamsTweetFields = "conversation_id"
+	UsersIdTweetsParamsTweetFieldsCreatedAt           UsersIdTweetsParamsTweetFields = "created_at"
+	UsersIdTweetsParamsTweetFieldsEditControls        UsersIdTweetsParamsTweetFields = "edit_controls"
+	UsersIdTweetsParamsTweetFieldsEditHistoryTweetIds UsersIdTweetsParamsTweetFields = "edit_history_tweet_ids"
+	UsersIdTweetsParamsTweetFieldsEntities            UsersIdTweetsParamsTweetFields = "entities"
+	UsersIdTweetsParamsTweetFieldsGeo                 UsersIdTweetsParamsTweetFields = "geo"
+	UsersIdTweetsParamsTweetFieldsId                  UsersIdTweetsParamsTweetFields = "id"
+	UsersIdTweetsParamsTweetFieldsInReplyToUserId     UsersIdTweetsParamsTweetFields = "in_reply_to_user_id"
+	UsersIdTweetsParamsTweetFieldsLang                UsersIdTweetsParamsTweetFields = "lang"
+	UsersIdTweetsParamsTweetFieldsNonPublicMetrics    UsersIdTweetsParamsTweetFields = "non_public_metrics"
+	UsersIdTweetsParamsTweetFieldsOrganicMetrics      UsersIdTweetsParamsTweetFields = "organic_metrics"
+	UsersIdTweetsParamsTweetFieldsPossiblySensitive   UsersIdTweetsParamsTweetFields = "possibly_sensitive"
+	UsersIdTweetsParamsTweetFieldsPromotedMetrics     UsersIdTweetsParamsTweetFields = "promoted_metrics"
+	UsersIdTweetsParamsTweetFieldsPublicMetrics       UsersIdTweetsParamsTweetFields = "public_metrics"
+	UsersIdTweetsParamsTweetFieldsReferencedTweets    UsersIdTweetsParamsTweetFields = "referenced_tweets"
+	UsersIdTweetsParamsTweetFieldsReplySettings       UsersIdTweetsParamsTweetFields = "reply_settings"
+	UsersIdTweetsParamsTweetFieldsSource              UsersIdTweetsParamsTweetFields = "source"
+	UsersIdTweetsParamsTweetFieldsText                UsersIdTweetsParamsTweetFields = "text"
+	UsersIdTweetsParamsTweetFieldsWithheld            UsersIdTweetsParamsTweetFields = "withheld"
+)
+
 // CashtagEntity defines model for CashtagEntity.
 type CashtagEntity struct {
 	// End Index (zero-based) at which position this entity ends.  The index is exclusive.
@@ -503,6 +535,29 @@ type Get2UsersIdOwnedListsResponse struct {
 	} `json:"meta,omitempty"`
 }
 
+// Get2UsersIdTweetsResponse defines model for Get2UsersIdTweetsResponse.
+type Get2UsersIdTweetsResponse struct {
+	Data     *[]Tweet    `json:"data,omitempty"`
+	Errors   *[]Problem  `json:"errors,omitempty"`
+	Includes *Expansions `json:"includes,omitempty"`
+	Meta     *struct {
+		// NewestId The newest id in this response.
+		NewestId *NewestId `json:"newest_id,omitempty"`
+
+		// NextToken The next token.
+		NextToken *NextToken `json:"next_token,omitempty"`
+
+		// OldestId The oldest id in this response.
+		OldestId *OldestId `json:"oldest_id,omitempty"`
+
+		// PreviousToken The previous token.
+		PreviousToken *PreviousToken `json:"previous_token,omitempty"`
+
+		// ResultCount The number of results returned in this response.
+		ResultCount *ResultCount `json:"result_count,omitempty"`
+	} `json:"meta,omitempty"`
+}
+
 // HashtagEntity defines model for HashtagEntity.
 type HashtagEntity struct {
 	// End Index (zero-based) at which position this entity ends.  The index is exclusive.
@@ -591,12 +646,21 @@ type MentionFields struct {
 	Username UserName `json:"username"`
 }
 
+// NewestId The newest id in this response.
+type NewestId = string
+
 // NextToken The next token.
 type NextToken = string
 
+// OldestId The oldest id in this response.
+type OldestId = string
+
 // PaginationToken32 A base32 pagination token.
 type PaginationToken32 = string
 
+// PaginationToken36 A base36 pagination token.
+type PaginationToken36 = string
+
 // PaginationTokenLong A 'long' pagination token.
 type PaginationTokenLong = string
 
@@ -1183,6 +1247,39 @@ type ListUserOwnedListsParamsExpansions string
 // ListUserOwnedListsParamsUserFields defines parameters for ListUserOwnedLists.
 type ListUserOwnedListsParamsUserFields string
 
+// UsersIdTweetsParams defines parameters for UsersIdTweets.
+type UsersIdTweetsParams struct {
+	// SinceId The minimum Tweet ID to be included in the result set. This parameter takes precedence over start_time if both are specified.
+	SinceId *TweetId `form:"since_id,omitempty" json:"since_id,omitempty"`
+
+	// UntilId The maximum Tweet ID to be included in the result set. This parameter takes precedence over end_time if both are specified.
+	UntilId *TweetId `form:"until_id,omitempty" json:"until_id,omitempty"`
+
+	// MaxResults The maximum number of results.
+	MaxResults *int32 `form:"max_results,omitempty" json:"max_results,omitempty"`
+
+	// PaginationToken This parameter is used to get the next 'page' of results.
+	PaginationToken *PaginationToken36 `form:"pagination_token,omitempty" json:"pagination_token,omitempty"`
+
+	// Exclude The set of entities to exclude (e.g. 'replies' or 'retweets').
+	Exclude *[]UsersIdTweetsParamsExclude `form:"exclude,omitempty" json:"exclude,omitempty"`
+
+	// StartTime YYYY-MM-DDTHH:mm:ssZ. The earliest UTC timestamp from which the Tweets will be provided. The since_id parameter takes precedence if it is also specified.
+	StartTime *time.Time `form:"start_time,omitempty" json:"start_time,omitempty"`
+
+	// EndTime YYYY-MM-DDTHH:mm:ssZ. The latest UTC timestamp to which the Tweets will be provided. The until_id parameter takes precedence if it is also specified.
+	EndTime *time.Time `form:"end_time,omitempty" json:"end_time,omitempty"`
+
+	// TweetFields A comma separated list of Tweet fields to display.
+	TweetFields *TweetFieldsParameter `form:"tweet.fields,omitempty" json:"tweet.fields,omitempty"`
+}
+
+// UsersIdTweetsParamsExclude defines parameters for UsersIdTweets.
+type UsersIdTweetsParamsExclude string
+
+// UsersIdTweetsParamsTweetFields defines parameters for UsersIdTweets.
+type UsersIdTweetsParamsTweetFields string
+
 // UsersIdFollowJSONRequestBody defines body for UsersIdFollow for application/json ContentType.
 type UsersIdFollowJSONRequestBody = UsersFollowingCreateRequest
 
@@ -1278,6 +1375,9 @@ type ClientInterface interface {
 
 	// ListUserOwnedLists request
 	ListUserOwnedLists(ctx context.Context, id UserId, params *ListUserOwnedListsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UsersIdTweets request
+	UsersIdTweets(ctx context.Context, id UserId, params *UsersIdTweetsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
 func (c *Client) ListGetMembers(ctx context.Context, id ListId, params *ListGetMembersParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -1364,6 +1464,18 @@ func (c *Client) ListUserOwnedLists(ctx context.Context, id UserId, params *List
 	return c.Client.Do(req)
 }
 
+func (c *Client) UsersIdTweets(ctx context.Context, id UserId, params *UsersIdTweetsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUsersIdTweetsRequest(c.Server, id, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 // NewListGetMembersRequest generates requests for ListGetMembers
 func NewListGetMembersRequest(server string, id ListId, params *ListGetMembersParams) (*http.Request, error) {
 	var err error
@@ -1969,6 +2081,172 @@ func NewListUserOwnedListsRequest(server string, id UserId, params *ListUserOwne
 	return req, nil
 }
 
+// NewUsersIdTweetsRequest generates requests for UsersIdTweets
+func NewUsersIdTweetsRequest(server string, id UserId, params *UsersIdTweetsParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "id", runtime.ParamLocationPath, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/2/users/%s/tweets", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	queryValues := queryURL.Query()
+
+	if params.SinceId != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "since_id", runtime.ParamLocationQuery, *params.SinceId); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.UntilId != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "until_id", runtime.ParamLocationQuery, *params.UntilId); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.MaxResults != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "max_results", runtime.ParamLocationQuery, *params.MaxResults); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.PaginationToken != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "pagination_token", runtime.ParamLocationQuery, *params.PaginationToken); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.Exclude != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", false, "exclude", runtime.ParamLocationQuery, *params.Exclude); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.StartTime != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "start_time", runtime.ParamLocationQuery, *params.StartTime); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.EndTime != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "end_time", runtime.ParamLocationQuery, *params.EndTime); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	if params.TweetFields != nil {
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", false, "tweet.fields", runtime.ParamLocationQuery, *params.TweetFields); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+	}
+
+	queryURL.RawQuery = queryValues.Encode()
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
 	for _, r := range c.RequestEditors {
 		if err := r(ctx, req); err != nil {
@@ -2031,6 +2309,9 @@ type ClientWithResponsesInterface interface {
 
 	// ListUserOwnedLists request
 	ListUserOwnedListsWithResponse(ctx context.Context, id UserId, params *ListUserOwnedListsParams, reqEditors ...RequestEditorFn) (*ListUserOwnedListsResponse, error)
+
+	// UsersIdTweets request
+	UsersIdTweetsWithResponse(ctx context.Context, id UserId, params *UsersIdTweetsParams, reqEditors ...RequestEditorFn) (*UsersIdTweetsResponse, error)
 }
 
 type ListGetMembersResponse struct {
@@ -2171,6 +2452,29 @@ func (r ListUserOwnedListsResponse) StatusCode() int {
 	return 0
 }
 
+type UsersIdTweetsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Get2UsersIdTweetsResponse
+	JSONDefault  *Problem
+}
+
+// Status returns HTTPResponse.Status
+func (r UsersIdTweetsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UsersIdTweetsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 // ListGetMembersWithResponse request returning *ListGetMembersResponse
 func (c *ClientWithResponses) ListGetMembersWithResponse(ctx context.Context, id ListId, params *ListGetMembersParams, reqEditors ...RequestEditorFn) (*ListGetMembersResponse, error) {
 	rsp, err := c.ListGetMembers(ctx, id, params, reqEditors...)
@@ -2233,6 +2537,15 @@ func (c *ClientWithResponses) ListUserOwnedListsWithResponse(ctx context.Context
 	return ParseListUserOwnedListsResponse(rsp)
 }
 
+// UsersIdTweetsWithResponse request returning *UsersIdTweetsResponse
+func (c *ClientWithResponses) UsersIdTweetsWithResponse(ctx context.Context, id UserId, params *UsersIdTweetsParams, reqEditors ...RequestEditorFn) (*UsersIdTweetsResponse, error) {
+	rsp, err := c.UsersIdTweets(ctx, id, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUsersIdTweetsResponse(rsp)
+}
+
 // ParseListGetMembersResponse parses an HTTP response from a ListGetMembersWithResponse call
 func ParseListGetMembersResponse(rsp *http.Response) (*ListGetMembersResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -2430,3 +2743,36 @@ func ParseListUserOwnedListsResponse(rsp *http.Response) (*ListUserOwnedListsRes
 
 	return response, nil
 }
+
+// ParseUsersIdTweetsResponse parses an HTTP response from a UsersIdTweetsWithResponse call
+func ParseUsersIdTweetsResponse(rsp *http.Response) (*UsersIdTweetsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UsersIdTweetsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Get2UsersIdTweetsResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Problem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
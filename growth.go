@@ -0,0 +1,302 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// GrowthUser is a followed account with its follower change over a window.
+// BaselineAt is the point the change is measured from; it is later than the
+// window start when history doesn't reach back that far.
+type GrowthUser struct {
+	FollowingUser
+	BaselineAt     string  `json:"baseline_at"`
+	FollowersDelta int     `json:"followers_delta"`
+	GrowthPct      float64 `json:"growth_pct"`
+}
+
+// DormantUser is a followed account whose tweet_count hasn't moved in a while.
+type DormantUser struct {
+	FollowingUser
+	LastTweetAt string `json:"last_tweet_at"`
+	IdleDays    int    `json:"idle_days"`
+}
+
+// baselinePoint returns the last point at or before since, or the first
+// point when history starts later.
+func baselinePoint(points []MetricPoint, since string) MetricPoint {
+	base := points[0]
+	for _, p := range points {
+		if p.At > since {
+			break
+		}
+		base = p
+	}
+	return base
+}
+
+// FindFastestGrowing ranks accounts in sourceUserId's latest following
+// snapshot by relative follower growth since the given time.
+func FindFastestGrowing(db *sql.DB, sourceUserId string, since time.Time, limit int) ([]GrowthUser, error) {
+	history, err := GetSnapshotMetricsHistory(db, "following_snapshots", sourceUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	sinceStr := since.UTC().Format(time.RFC3339)
+	growth := make(map[string]GrowthUser)
+	var ids []string
+	for id, points := range history {
+		if len(points) < 2 {
+			continue
+		}
+		base, last := baselinePoint(points, sinceStr), points[len(points)-1]
+		delta := last.Followers - base.Followers
+		if delta <= 0 {
+			continue
+		}
+		g := GrowthUser{BaselineAt: base.At, FollowersDelta: delta}
+		if base.Followers > 0 {
+			g.GrowthPct = float64(delta) / float64(base.Followers) * 100
+		}
+		growth[id] = g
+		ids = append(ids, id)
+	}
+
+	users, err := GetUsersByIDs(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]GrowthUser, 0, len(users))
+	for _, u := range users {
+		g := growth[u.Id]
+		g.FollowingUser = u
+		result = append(result, g)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].GrowthPct != result[j].GrowthPct {
+			return result[i].GrowthPct > result[j].GrowthPct
+		}
+		return result[i].FollowersDelta > result[j].FollowersDelta
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// FindDormant returns accounts in sourceUserId's latest following snapshot
+// whose tweet_count has been unchanged for at least idleDays, as observed
+// between the point it last changed and the user's last refresh.
+func FindDormant(db *sql.DB, sourceUserId string, idleDays int) ([]DormantUser, error) {
+	history, err := GetSnapshotMetricsHistory(db, "following_snapshots", sourceUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	lastChange := make(map[string]string)
+	var ids []string
+	for id, points := range history {
+		// Start of the trailing run of points with the current tweet_count.
+		i := len(points) - 1
+		for i > 0 && points[i-1].Tweets == points[i].Tweets {
+			i--
+		}
+		lastChange[id] = points[i].At
+		ids = append(ids, id)
+	}
+
+	users, err := GetUsersByIDs(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	var result []DormantUser
+	for _, u := range users {
+		changed, err := time.Parse(time.RFC3339, lastChange[u.Id])
+		if err != nil {
+			continue
+		}
+		seen, err := parseDBTime(u.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		idle := int(seen.Sub(changed).Hours() / 24)
+		if idle < idleDays {
+			continue
+		}
+		result = append(result, DormantUser{FollowingUser: u, LastTweetAt: lastChange[u.Id], IdleDays: idle})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].IdleDays > result[j].IdleDays })
+	return result, nil
+}
+
+// parseDBTime parses both RFC3339 and SQLite CURRENT_TIMESTAMP values.
+func parseDBTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateTime, s)
+}
+
+// GrowthPoint is the account at the end of a day. Followers/Following are the
+// counts X reports (public_metrics); SnapshotFollowers/SnapshotFollowing are
+// the sizes of the fetched lists, which lag behind and can differ. Each is nil
+// until its series has a first value. Gained and Lost are how many followers
+// the snapshots taken that day gained or lost.
+type GrowthPoint struct {
+	Date              string `json:"date"`
+	Followers         *int   `json:"followers"`
+	Following         *int   `json:"following"`
+	SnapshotFollowers *int   `json:"snapshot_followers"`
+	SnapshotFollowing *int   `json:"snapshot_following"`
+	Gained            int    `json:"gained"`
+	Lost              int    `json:"lost"`
+}
+
+// AccountGrowth is the daily series of an own account over a range.
+// NetChange follows the public_metrics followers series from its first known
+// value in the range.
+type AccountGrowth struct {
+	UserID    string        `json:"user_id"`
+	Range     string        `json:"range"`
+	Points    []GrowthPoint `json:"points"`
+	NetChange int           `json:"net_change"`
+	Gained    int           `json:"gained"`
+	Lost      int           `json:"lost"`
+}
+
+// growthRanges maps the ranges GetAccountGrowth accepts to days; 0 is all history.
+var growthRanges = map[string]int{"7d": 7, "30d": 30, "90d": 90, "1y": 365, "all": 0}
+
+// observation is one known value of some of the series (-1 = unknown).
+type observation struct {
+	at                                   time.Time
+	followers, following                 int
+	snapshotFollowers, snapshotFollowing int
+}
+
+// BuildAccountGrowth builds one point per day from the metrics history of
+// userId and, as separate series, the sizes of its followers/following
+// snapshots, carrying each series' last known value forward. Follower
+// snapshot diffs are attributed to the day of the later snapshot.
+func BuildAccountGrowth(db *sql.DB, userId, rangeKey string) (AccountGrowth, error) {
+	growth := AccountGrowth{UserID: userId, Range: rangeKey}
+	days, ok := growthRanges[rangeKey]
+	if !ok {
+		days, growth.Range = 30, "30d"
+	}
+
+	var obs []observation
+	history, err := GetUserMetricsHistory(db, userId)
+	if err != nil {
+		return growth, err
+	}
+	for _, p := range history {
+		if t, err := time.Parse(time.RFC3339, p.At); err == nil {
+			obs = append(obs, observation{t, p.Followers, p.Following, -1, -1})
+		}
+	}
+
+	followerSnaps, err := GetSnapshotTimestamps(db, "followers_snapshots", userId)
+	if err != nil {
+		return growth, err
+	}
+	for _, s := range followerSnaps {
+		if t, err := time.Parse(time.RFC3339, s.FetchedAt); err == nil {
+			obs = append(obs, observation{t, -1, -1, s.Count, -1})
+		}
+	}
+	followingSnaps, err := GetSnapshotTimestamps(db, "following_snapshots", userId)
+	if err != nil {
+		return growth, err
+	}
+	for _, s := range followingSnaps {
+		if t, err := time.Parse(time.RFC3339, s.FetchedAt); err == nil {
+			obs = append(obs, observation{t, -1, -1, -1, s.Count})
+		}
+	}
+	if len(obs) == 0 {
+		return growth, nil
+	}
+	sort.SliceStable(obs, func(i, j int) bool { return obs[i].at.Before(obs[j].at) })
+
+	// Gained/lost per day from consecutive followers snapshots (stored newest first).
+	type change struct{ gained, lost int }
+	changes := make(map[string]change)
+	for i := len(followerSnaps) - 1; i > 0; i-- {
+		from, to := followerSnaps[i].FetchedAt, followerSnaps[i-1].FetchedAt
+		gained, lost, err := GetSnapshotDiff(db, "followers_snapshots", userId, from, to)
+		if err != nil {
+			return growth, err
+		}
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			continue
+		}
+		day := t.Local().Format(time.DateOnly)
+		c := changes[day]
+		c.gained += len(gained)
+		c.lost += len(lost)
+		changes[day] = c
+	}
+
+	today := startOfDay(time.Now())
+	first := startOfDay(obs[0].at.Local())
+	if days > 0 && today.AddDate(0, 0, -days+1).After(first) {
+		first = today.AddDate(0, 0, -days+1)
+	}
+
+	last := observation{followers: -1, following: -1, snapshotFollowers: -1, snapshotFollowing: -1}
+	next := 0
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		for next < len(obs) && obs[next].at.Before(end) {
+			o := obs[next]
+			if o.followers >= 0 {
+				last.followers = o.followers
+			}
+			if o.following >= 0 {
+				last.following = o.following
+			}
+			if o.snapshotFollowers >= 0 {
+				last.snapshotFollowers = o.snapshotFollowers
+			}
+			if o.snapshotFollowing >= 0 {
+				last.snapshotFollowing = o.snapshotFollowing
+			}
+			next++
+		}
+		key := day.Format(time.DateOnly)
+		c := changes[key]
+		growth.Points = append(growth.Points, GrowthPoint{
+			Date:              key,
+			Followers:         knownCount(last.followers),
+			Following:         knownCount(last.following),
+			SnapshotFollowers: knownCount(last.snapshotFollowers),
+			SnapshotFollowing: knownCount(last.snapshotFollowing),
+			Gained:            c.gained,
+			Lost:              c.lost,
+		})
+		growth.Gained += c.gained
+		growth.Lost += c.lost
+	}
+
+	// Net change is measured from the first day with a known value; once
+	// known, the series stays known through the last day.
+	for _, p := range growth.Points {
+		if p.Followers != nil {
+			growth.NetChange = *growth.Points[len(growth.Points)-1].Followers - *p.Followers
+			break
+		}
+	}
+	return growth, nil
+}
+
+// knownCount returns nil for an unknown (-1) series value.
+func knownCount(v int) *int {
+	if v < 0 {
+		return nil
+	}
+	return &v
+}
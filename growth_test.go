@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-twitter-follower/gen"
+)
+
+func TestBuildAccountGrowthKeepsSourcesApart(t *testing.T) {
+	db := newTestDB(t)
+	const acct = "1"
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT INTO user_metrics_history (user_id, followers_count, following_count, recorded_at) VALUES (?, 500, 300, ?)`,
+		acct, now.AddDate(0, 0, -2).Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+	insertSnapshot(t, db, "followers_snapshots", acct, now.AddDate(0, 0, -1).Format(time.RFC3339), "a", "b")
+
+	growth, err := BuildAccountGrowth(db, acct, "7d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(growth.Points) != 3 {
+		t.Fatalf("got %d points, want 3", len(growth.Points))
+	}
+	first, last := growth.Points[0], growth.Points[len(growth.Points)-1]
+	if first.SnapshotFollowers != nil {
+		t.Errorf("snapshot size before the first snapshot = %d, want unknown", *first.SnapshotFollowers)
+	}
+	if last.Followers == nil || last.Following == nil || *last.Followers != 500 || *last.Following != 300 {
+		t.Errorf("reported counts %v/%v, want 500/300 from public_metrics", last.Followers, last.Following)
+	}
+	if last.SnapshotFollowers == nil || *last.SnapshotFollowers != 2 || last.SnapshotFollowing != nil {
+		t.Errorf("snapshot sizes %v/%v, want 2 and unknown", last.SnapshotFollowers, last.SnapshotFollowing)
+	}
+	if growth.NetChange != 0 {
+		t.Errorf("net change %d, want 0 with one metrics point", growth.NetChange)
+	}
+}
+
+func TestBuildAccountGrowthNetChangeFromFirstKnownValue(t *testing.T) {
+	db := newTestDB(t)
+	const acct = "1"
+	now := time.Now().UTC()
+	insertSnapshot(t, db, "followers_snapshots", acct, now.AddDate(0, 0, -3).Format(time.RFC3339), "a")
+	for i, followers := range []int{0, 5} {
+		if _, err := db.Exec(`INSERT INTO user_metrics_history (user_id, followers_count, following_count, recorded_at) VALUES (?, ?, 10, ?)`,
+			acct, followers, now.AddDate(0, 0, i-2).Format(time.RFC3339)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	growth, err := BuildAccountGrowth(db, acct, "7d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(growth.Points) != 4 {
+		t.Fatalf("got %d points, want 4", len(growth.Points))
+	}
+	if p := growth.Points[0]; p.Followers != nil {
+		t.Errorf("followers before the first metrics point = %d, want unknown", *p.Followers)
+	}
+	if p := growth.Points[1]; p.Followers == nil || *p.Followers != 0 {
+		t.Errorf("followers on the first metrics day = %v, want a known 0", p.Followers)
+	}
+	if growth.NetChange != 5 {
+		t.Errorf("net change %d, want 5", growth.NetChange)
+	}
+}
+
+// insertMetrics records one metrics history point of id.
+func insertMetrics(t *testing.T, db *sql.DB, id, at string, followers, tweets int) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO user_metrics_history (user_id, followers_count, following_count, tweet_count, listed_count, recorded_at) VALUES (?, ?, 0, ?, 0, ?)`,
+		id, followers, tweets, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindFastestGrowing(t *testing.T) {
+	db := newTestDB(t)
+	const acct = "1"
+	insertSnapshot(t, db, "following_snapshots", acct, "2026-01-10T00:00:00Z", "slow", "fast", "shrinking", "once")
+	for id, counts := range map[string][3]int{
+		"slow":      {1000, 1100, 1200}, // +100 since the window start
+		"fast":      {100, 150, 200},    // +50, but +33%
+		"shrinking": {500, 400, 300},
+	} {
+		for i, n := range counts {
+			insertMetrics(t, db, id, fmt.Sprintf("2026-01-0%dT00:00:00Z", 1+3*i), n, 0)
+		}
+	}
+	insertMetrics(t, db, "once", "2026-01-01T00:00:00Z", 10, 0)
+
+	growth, err := FindFastestGrowing(db, acct, time.Date(2026, 1, 4, 12, 0, 0, 0, time.UTC), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(growth) != 2 || growth[0].Id != "fast" || growth[1].Id != "slow" {
+		t.Fatalf("growth = %+v, want fast then slow", growth)
+	}
+	if g := growth[0]; g.FollowersDelta != 50 || g.BaselineAt != "2026-01-04T00:00:00Z" {
+		t.Errorf("fast delta %d from %s, want 50 from 2026-01-04", g.FollowersDelta, g.BaselineAt)
+	}
+
+	if growth, _ := FindFastestGrowing(db, acct, time.Date(2026, 1, 4, 12, 0, 0, 0, time.UTC), 1); len(growth) != 1 {
+		t.Errorf("limit 1 returned %d users", len(growth))
+	}
+}
+
+func TestFindDormant(t *testing.T) {
+	db := newTestDB(t)
+	const acct = "1"
+	insertSnapshot(t, db, "following_snapshots", acct, "2026-02-01T00:00:00Z", "quiet", "active")
+	insertMetrics(t, db, "quiet", "2026-01-01T00:00:00Z", 10, 5)
+	insertMetrics(t, db, "quiet", "2026-01-02T00:00:00Z", 12, 5)
+	insertMetrics(t, db, "active", "2026-01-01T00:00:00Z", 10, 5)
+	insertMetrics(t, db, "active", "2026-01-30T00:00:00Z", 10, 6)
+	if _, err := db.Exec(`UPDATE users SET updated_at = '2026-02-01 00:00:00'`); err != nil {
+		t.Fatal(err)
+	}
+
+	dormant, err := FindDormant(db, acct, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dormant) != 1 || dormant[0].Id != "quiet" {
+		t.Fatalf("dormant = %+v, want quiet only", dormant)
+	}
+	if d := dormant[0]; d.IdleDays != 31 || d.LastTweetAt != "2026-01-01T00:00:00Z" {
+		t.Errorf("quiet idle %d days since %s, want 31 since 2026-01-01", d.IdleDays, d.LastTweetAt)
+	}
+}
+
+func TestUpsertUserWithoutMetricsKeepsCounts(t *testing.T) {
+	db := newTestDB(t)
+	for _, body := range []string{
+		`{"id": "7", "username": "u7", "public_metrics": {"followers_count": 500, "following_count": 20, "tweet_count": 80, "listed_count": 1}}`,
+		`{"id": "7", "username": "u7_renamed"}`,
+	} {
+		var user gen.User
+		if err := json.Unmarshal([]byte(body), &user); err != nil {
+			t.Fatal(err)
+		}
+		if err := UpsertUser(db, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	followers, following, ok := GetUserCounts(db, "7")
+	if !ok || followers != 500 || following != 20 {
+		t.Errorf("counts %d/%d after an upsert without metrics, want 500/20 kept", followers, following)
+	}
+	if history, _ := GetUserMetricsHistory(db, "7"); len(history) != 1 {
+		t.Errorf("%d history points, want only the one with metrics", len(history))
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// importTable describes how rows of one ExportPayload table are loaded.
+// Rows are only ever read through columns, so payload keys never reach SQL.
+type importTable struct {
+	name     string
+	columns  []string
+	required []string
+	// insert is the statement run per row with columns as arguments; it
+	// affects no row when the row is a duplicate or older than the local one.
+	insert string
+	// replaceInsert, when set, is run instead of insert in replace mode. The
+	// table is then not emptied first; rows whose key is missing from the
+	// payload are deleted afterwards.
+	replaceInsert string
+	key           string
+	rows          func(p *ExportPayload) []map[string]interface{}
+}
+
+var importTables = []importTable{
+	{
+		name: "users",
+		columns: []string{"id", "username", "name", "description", "followers_count", "following_count",
+			"tweet_count", "listed_count", "verified", "verified_type", "profile_image_url", "location",
+			"created_at", "updated_at"},
+		required: []string{"id", "username"},
+		// Keep whichever copy of a user was refreshed last.
+		insert: `INSERT INTO users (id, username, name, description, followers_count, following_count,
+				tweet_count, listed_count, verified, verified_type, profile_image_url, location, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP))
+			ON CONFLICT(id) DO UPDATE SET
+				username = excluded.username, name = excluded.name, description = excluded.description,
+				followers_count = excluded.followers_count, following_count = excluded.following_count,
+				tweet_count = excluded.tweet_count, listed_count = excluded.listed_count,
+				verified = excluded.verified, verified_type = excluded.verified_type,
+				profile_image_url = excluded.profile_image_url, location = excluded.location,
+				created_at = excluded.created_at, updated_at = excluded.updated_at
+			WHERE excluded.updated_at > COALESCE(users.updated_at, '')`,
+		rows: func(p *ExportPayload) []map[string]interface{} { return p.Users },
+	},
+	snapshotImportTable("following_snapshots", func(p *ExportPayload) []map[string]interface{} { return p.FollowingSnapshots }),
+	snapshotImportTable("followers_snapshots", func(p *ExportPayload) []map[string]interface{} { return p.FollowersSnapshots }),
+	{
+		name:     "accounts",
+		columns:  []string{"user_id", "username", "bearer_token", "is_active", "created_at"},
+		required: []string{"user_id", "username"},
+		// Local accounts win; their tokens are never overwritten. Redacted or
+		// excluded tokens import as empty, to be set again with accounts add.
+		insert: `INSERT OR IGNORE INTO accounts (user_id, username, bearer_token, is_active, created_at)
+			VALUES (?, ?, COALESCE(NULLIF(?, '` + redactedValue + `'), ''), COALESCE(?, 1), COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP))`,
+		// A replace takes the exported accounts but never writes an empty
+		// (redacted or excluded) token over a local one.
+		replaceInsert: `INSERT INTO accounts (user_id, username, bearer_token, is_active, created_at)
+			VALUES (?, ?, COALESCE(NULLIF(?, '` + redactedValue + `'), ''), COALESCE(?, 1), COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP))
+			ON CONFLICT(user_id) DO UPDATE SET
+				username = excluded.username, is_active = excluded.is_active, created_at = excluded.created_at,
+				bearer_token = CASE WHEN excluded.bearer_token = '' THEN accounts.bearer_token ELSE excluded.bearer_token END`,
+		key:  "user_id",
+		rows: func(p *ExportPayload) []map[string]interface{} { return p.Accounts },
+	},
+	{
+		name:     "list_cache",
+		columns:  []string{"list_id", "owner_user_id", "name", "description", "member_count", "private", "fetched_at"},
+		required: []string{"list_id", "owner_user_id", "name", "fetched_at"},
+		insert: `INSERT INTO list_cache (list_id, owner_user_id, name, description, member_count, private, fetched_at)
+			VALUES (?, ?, ?, COALESCE(?, ''), COALESCE(?, 0), COALESCE(?, 0), ?)
+			ON CONFLICT(list_id, owner_user_id) DO UPDATE SET
+				name = excluded.name, description = excluded.description, member_count = excluded.member_count,
+				private = excluded.private, fetched_at = excluded.fetched_at
+			WHERE excluded.fetched_at > list_cache.fetched_at`,
+		rows: func(p *ExportPayload) []map[string]interface{} { return p.ListCache },
+	},
+	{
+		name:     "list_member_cache",
+		columns:  []string{"list_id", "user_id", "fetched_at"},
+		required: []string{"list_id", "user_id", "fetched_at"},
+		insert: `INSERT INTO list_member_cache (list_id, user_id, fetched_at) VALUES (?, ?, ?)
+			ON CONFLICT(list_id, user_id) DO UPDATE SET fetched_at = excluded.fetched_at
+			WHERE excluded.fetched_at > list_member_cache.fetched_at`,
+		rows: func(p *ExportPayload) []map[string]interface{} { return p.ListMemberCache },
+	},
+	{
+		name:     "fetch_logs",
+		columns:  []string{"endpoint", "user_id", "status_code", "created_at"},
+		required: []string{"endpoint", "created_at"},
+		insert: `INSERT INTO fetch_logs (endpoint, user_id, status_code, created_at)
+			SELECT ?1, ?2, ?3, ?4
+			WHERE NOT EXISTS (
+				SELECT 1 FROM fetch_logs WHERE endpoint = ?1 AND user_id IS ?2 AND created_at = ?4
+			)`,
+		rows: func(p *ExportPayload) []map[string]interface{} { return p.FetchLogs },
+	},
+}
+
+// snapshotImportTable loads snapshot rows, de-duplicated by
+// (source, target, fetched_at); exported row ids are not kept.
+func snapshotImportTable(table string, rows func(p *ExportPayload) []map[string]interface{}) importTable {
+	return importTable{
+		name:     table,
+		columns:  []string{"source_user_id", "target_user_id", "fetched_at"},
+		required: []string{"source_user_id", "target_user_id", "fetched_at"},
+		insert: fmt.Sprintf(`INSERT INTO %[1]s (source_user_id, target_user_id, fetched_at)
+			SELECT ?1, ?2, ?3
+			WHERE NOT EXISTS (
+				SELECT 1 FROM %[1]s WHERE source_user_id = ?1 AND fetched_at = ?3 AND target_user_id = ?2
+			)`, table),
+		rows: rows,
+	}
+}
+
+// ImportCount is the outcome of importing one table.
+type ImportCount struct {
+	Table    string `json:"table"`
+	Rows     int    `json:"rows"`
+	Imported int    `json:"imported"`
+	Skipped  int    `json:"skipped"`
+}
+
+type ImportResult struct {
+	Replace bool          `json:"replace"`
+	Tables  []ImportCount `json:"tables"`
+}
+
+// ParseExportPayload decodes and validates an ExportData document.
+func ParseExportPayload(data []byte) (*ExportPayload, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	var p ExportPayload
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid export JSON: %w", err)
+	}
+	if p.ExportedAt == "" {
+		return nil, errors.New("invalid export: exported_at missing")
+	}
+
+	for _, t := range importTables {
+		for i, row := range t.rows(&p) {
+			for _, col := range t.required {
+				if s, ok := row[col].(string); !ok || strings.TrimSpace(s) == "" {
+					return nil, fmt.Errorf("invalid export: %s[%d].%s must be a non-empty string", t.name, i, col)
+				}
+			}
+			for _, col := range t.columns {
+				switch row[col].(type) {
+				case nil, string, json.Number, bool:
+				default:
+					return nil, fmt.Errorf("invalid export: %s[%d].%s has unsupported type", t.name, i, col)
+				}
+			}
+		}
+	}
+	return &p, nil
+}
+
+// deleteMissingRows removes the rows of t within scope whose key is not
+// among rows.
+func deleteMissingRows(tx *sql.Tx, t importTable, rows []map[string]interface{}, scope exportFilter) error {
+	conds := []string{"1"}
+	var args []interface{}
+	if where, ok := scope.where[t.name]; ok {
+		conds[0] = where
+		args = append(args, scope.args[t.name]...)
+	}
+	if len(rows) > 0 {
+		conds = append(conds, t.key+` NOT IN (?`+strings.Repeat(`, ?`, len(rows)-1)+`)`)
+		for _, row := range rows {
+			args = append(args, importArg(row[t.key]))
+		}
+	}
+	query := `DELETE FROM ` + t.name + ` WHERE ` + strings.Join(conds, " AND ")
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("clearing %s: %w", t.name, err)
+	}
+	return nil
+}
+
+// importArg converts a decoded JSON value to a SQLite argument.
+func importArg(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	return v
+}
+
+// ImportPayload loads p into db in one transaction. Merge keeps local rows
+// and adds what is missing or newer; replace first empties the tables the
+// payload contains (and drops tokens of accounts no longer present), limited
+// to the payload's scope. Local bearer tokens survive a replace with a
+// redacted export.
+func ImportPayload(db *sql.DB, p *ExportPayload, replace bool) (ImportResult, error) {
+	result := ImportResult{Replace: replace}
+	scope, err := newExportFilter(p.Scope.options())
+	if err != nil {
+		return result, fmt.Errorf("invalid export scope: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		// Cleared in reverse, as list_member_cache is scoped through list_cache.
+		for i := len(importTables) - 1; i >= 0; i-- {
+			t := importTables[i]
+			// Tables left out of a scoped export (null) are kept.
+			if t.rows(p) == nil || t.replaceInsert != "" {
+				continue
+			}
+			query := `DELETE FROM ` + t.name
+			if where, ok := scope.where[t.name]; ok {
+				// Scoped users may still be referenced outside the scope, so
+				// they are merged instead.
+				if t.name == "users" {
+					continue
+				}
+				query += ` WHERE ` + where
+			}
+			if _, err := tx.Exec(query, scope.args[t.name]...); err != nil {
+				return result, fmt.Errorf("clearing %s: %w", t.name, err)
+			}
+		}
+	}
+
+	for _, t := range importTables {
+		insert := t.insert
+		if replace && t.replaceInsert != "" {
+			insert = t.replaceInsert
+		}
+		stmt, err := tx.Prepare(insert)
+		if err != nil {
+			return result, fmt.Errorf("preparing %s: %w", t.name, err)
+		}
+		count := ImportCount{Table: t.name}
+		for i, row := range t.rows(p) {
+			args := make([]interface{}, len(t.columns))
+			for j, col := range t.columns {
+				args[j] = importArg(row[col])
+			}
+			res, err := stmt.Exec(args...)
+			if err != nil {
+				stmt.Close()
+				return result, fmt.Errorf("importing %s[%d]: %w", t.name, i, err)
+			}
+			count.Rows++
+			if n, _ := res.RowsAffected(); n > 0 {
+				count.Imported++
+			} else {
+				count.Skipped++
+			}
+		}
+		stmt.Close()
+		result.Tables = append(result.Tables, count)
+
+		if replace && t.replaceInsert != "" && t.rows(p) != nil {
+			if err := deleteMissingRows(tx, t, t.rows(p), scope); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if replace {
+		for _, table := range []string{"oauth1_tokens", "oauth2_tokens"} {
+			_, err := tx.Exec(`DELETE FROM ` + table + ` WHERE account_user_id NOT IN (SELECT user_id FROM accounts)`)
+			if err != nil {
+				return result, fmt.Errorf("clearing %s: %w", table, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// roundTrip exports a.db with opts and parses the JSON back like `import` does.
+func roundTrip(t *testing.T, a *App, opts ExportOptions) *ExportPayload {
+	t.Helper()
+	payload, err := BuildExport(a.db, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseExportPayload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func bearerToken(t *testing.T, a *App) string {
+	t.Helper()
+	acct, err := GetAccountByUserID(a.db, fakeAccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acct.BearerToken
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	a, _ := newTestApp(t)
+	acct := testAccount(t, a)
+	if _, err := a.fetchFollowingForAccount(context.Background(), acct); err != nil {
+		t.Fatal(err)
+	}
+	payload := roundTrip(t, a, ExportOptions{})
+
+	b := &App{db: newTestDB(t)}
+	result, err := ImportPayload(b.db, payload, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"users", "following_snapshots", "accounts"} {
+		src := countRows(t, a, `SELECT COUNT(*) FROM `+table)
+		if got := countRows(t, b, `SELECT COUNT(*) FROM `+table); got != src {
+			t.Errorf("%s: imported %d rows, want %d", table, got, src)
+		}
+	}
+	if got := bearerToken(t, b); got != "" {
+		t.Errorf("redacted token imported as %q, want empty", got)
+	}
+
+	// Importing the same payload again adds nothing.
+	result, err = ImportPayload(b.db, payload, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range result.Tables {
+		if c.Imported != 0 {
+			t.Errorf("second import added %d %s rows", c.Imported, c.Table)
+		}
+	}
+}
+
+func TestScopedReplaceImportKeepsRowsOutsideScope(t *testing.T) {
+	a, _ := newTestApp(t)
+	if _, err := a.fetchFollowingForAccount(context.Background(), testAccount(t, a)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.db.Exec(`UPDATE following_snapshots SET fetched_at = '2020-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.db.Exec(`INSERT INTO following_snapshots (source_user_id, target_user_id, fetched_at)
+		VALUES (?, ?, '2021-01-01T00:00:00Z'), ('9', ?, '2020-01-01T00:00:00Z')`,
+		fakeAccountID, fakeUserID(0), fakeUserID(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := roundTrip(t, a, ExportOptions{AccountUserID: fakeAccountID, To: "2020-12-31"})
+	if payload.Scope == nil || payload.Scope.To != "2020-12-31" {
+		t.Fatalf("scope = %+v, want it recorded", payload.Scope)
+	}
+	if _, err := ImportPayload(a.db, payload, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := countRows(t, a, `SELECT COUNT(*) FROM following_snapshots WHERE fetched_at = '2020-01-01T00:00:00Z' AND source_user_id = ?`, fakeAccountID); got != 220 {
+		t.Errorf("scoped snapshot has %d rows after replace, want 220", got)
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM following_snapshots WHERE fetched_at = '2021-01-01T00:00:00Z'`); got != 1 {
+		t.Error("snapshot after the scope was deleted")
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM following_snapshots WHERE source_user_id = '9'`); got != 1 {
+		t.Error("snapshot of another account was deleted")
+	}
+}
+
+func TestReplaceImportKeepsLocalToken(t *testing.T) {
+	for _, credentials := range []string{credentialsRedact, credentialsExclude} {
+		t.Run(credentials, func(t *testing.T) {
+			a, _ := newTestApp(t)
+			token := bearerToken(t, a)
+
+			payload := roundTrip(t, a, ExportOptions{Credentials: credentials})
+			if _, err := ImportPayload(a.db, payload, true); err != nil {
+				t.Fatal(err)
+			}
+			if got := bearerToken(t, a); got != token {
+				t.Errorf("token after replace = %q, want %q", got, token)
+			}
+		})
+	}
+}
+
+func TestReplaceImportTakesIncludedToken(t *testing.T) {
+	a, _ := newTestApp(t)
+	payload := roundTrip(t, a, ExportOptions{Credentials: credentialsInclude})
+	payload.Accounts[0]["bearer_token"] = "rotated"
+	if _, err := ImportPayload(a.db, payload, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := bearerToken(t, a); got != "rotated" {
+		t.Errorf("token after replace = %q, want the exported one", got)
+	}
+}
@@ -29,6 +29,18 @@ type Config struct {
 	// OAuth1 after PIN entered access token
 	AccessToken       string
 	AccessTokenSecret string
+
+	// OAuth2 PKCE client (user-context tokens are stored per account)
+	OAuth2ClientId     string
+	OAuth2ClientSecret string
+	OAuth2RedirectURL  string
+
+	// X API root, defaults to https://api.twitter.com
+	APIBaseURL string
+
+	// JSONL cassette of X API traffic, "record" or "replay" (default)
+	CassettePath string
+	CassetteMode string
 }
 
 func GetConfig() *Config {
@@ -54,10 +66,22 @@ func GetConfig() *Config {
 		ApiKeySecret:      os.Getenv("API_KEY_SECRET"),
 		AccessToken:       os.Getenv("ACCESS_TOKEN"),
 		AccessTokenSecret: os.Getenv("ACCESS_TOKEN_SECRET"),
+
+		OAuth2ClientId:     os.Getenv("OAUTH2_CLIENT_ID"),
+		OAuth2ClientSecret: os.Getenv("OAUTH2_CLIENT_SECRET"),
+		OAuth2RedirectURL:  os.Getenv("OAUTH2_REDIRECT_URL"),
+
+		APIBaseURL:   os.Getenv("X_API_BASE_URL"),
+		CassettePath: os.Getenv("X_CASSETTE"),
+		CassetteMode: os.Getenv("X_CASSETTE_MODE"),
 	}
 }
 
 func main() {
+	if len(os.Args) > 1 && isCLICommand(os.Args[1]) {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
 	app := NewApp()
 
 	frontendFS, fsErr := fs.Sub(assets, "frontend")
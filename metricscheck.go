@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	metricsCheckInterval = time.Hour
+	// One FindUserByUsername lookup per account per day is enough for a daily series.
+	metricsCheckMaxAge = 24 * time.Hour
+)
+
+// runMetricsCheck periodically records each account's own public metrics
+// until ctx is done, so the growth chart has points between snapshot fetches.
+func (a *App) runMetricsCheck(ctx context.Context) {
+	a.checkAccountMetrics(ctx, time.Now())
+	ticker := time.NewTicker(metricsCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkAccountMetrics(ctx, time.Now())
+		}
+	}
+}
+
+func (a *App) checkAccountMetrics(ctx context.Context, now time.Time) {
+	accounts, err := GetAllAccounts(a.db)
+	if err != nil {
+		log.Printf("[metrics] Error listing accounts: %v", err)
+		return
+	}
+	for _, acct := range accounts {
+		if last, ok := GetLastFetchAt(a.db, endpointUserByName, acct.UserID); ok && now.Sub(last) < metricsCheckMaxAge {
+			continue
+		}
+		est := FetchEstimate{Endpoint: endpointUserByName, TargetID: acct.UserID, Items: 1, Pages: 1, Known: true}
+		est.Cost = GetUnitPrice(a.db, endpointUserByName)
+		if decision := CheckBudget(a.db, acct.UserID, est); !decision.Allowed {
+			log.Printf("[metrics] @%s: %s", acct.Username, decision.Message)
+			continue
+		}
+
+		client, err := NewAccountClient(acct)
+		if err != nil {
+			log.Printf("[metrics] Error creating client for @%s: %v", acct.Username, err)
+			continue
+		}
+		user, err := LookupUser(ctx, client, acct.Username)
+		if err != nil {
+			log.Printf("[metrics] Error looking up @%s: %v", acct.Username, err)
+			continue
+		}
+		if err := UpsertUser(a.db, *user); err != nil {
+			log.Printf("[metrics] Error saving @%s: %v", acct.Username, err)
+			continue
+		}
+		LogFetch(a.db, endpointUserByName, acct.UserID, 200)
+	}
+}
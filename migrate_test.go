@@ -0,0 +1,42 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateDBIsIdempotent(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := newTestDB(t)
+	if err := migrateDB(db); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	version, err := schemaVersion(db)
+	if err != nil || version != len(migrations) {
+		t.Errorf("schema version %d (%v), want %d", version, err, len(migrations))
+	}
+}
+
+func TestMigrateDBRefusesNewerSchema(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "newer.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := migrateDB(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (999, '0999_future', '2030-01-01T00:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+
+	err = migrateDB(db)
+	if err == nil || !strings.Contains(err.Error(), "schema version 999") {
+		t.Fatalf("err = %v, want the newer schema refused", err)
+	}
+}
@@ -0,0 +1,218 @@
+-- Schema as of the introduction of versioned migrations. IF NOT EXISTS keeps
+-- it safe on databases created before schema_version existed.
+
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	username TEXT NOT NULL,
+	name TEXT,
+	description TEXT,
+	followers_count INTEGER,
+	following_count INTEGER,
+	tweet_count INTEGER,
+	listed_count INTEGER,
+	verified INTEGER DEFAULT 0,
+	verified_type TEXT,
+	profile_image_url TEXT,
+	created_at TEXT,
+	location TEXT,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS following_snapshots (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	source_user_id TEXT NOT NULL,
+	target_user_id TEXT NOT NULL,
+	fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_snapshots_source ON following_snapshots(source_user_id, fetched_at);
+CREATE INDEX IF NOT EXISTS idx_snapshots_target ON following_snapshots(target_user_id);
+
+CREATE TABLE IF NOT EXISTS fetch_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	endpoint TEXT,
+	user_id TEXT,
+	status_code INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id TEXT NOT NULL UNIQUE,
+	username TEXT NOT NULL,
+	bearer_token TEXT NOT NULL,
+	is_active INTEGER DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS list_cache (
+	list_id TEXT NOT NULL,
+	owner_user_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT DEFAULT '',
+	member_count INTEGER DEFAULT 0,
+	private INTEGER DEFAULT 0,
+	fetched_at TEXT NOT NULL,
+	PRIMARY KEY (list_id, owner_user_id)
+);
+
+CREATE TABLE IF NOT EXISTS list_member_cache (
+	list_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_member_user ON list_member_cache(user_id);
+
+CREATE TABLE IF NOT EXISTS followers_snapshots (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	source_user_id TEXT NOT NULL,
+	target_user_id TEXT NOT NULL,
+	fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_followers_source ON followers_snapshots(source_user_id, fetched_at);
+CREATE INDEX IF NOT EXISTS idx_followers_target ON followers_snapshots(target_user_id);
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+	endpoint TEXT NOT NULL,
+	token_key TEXT NOT NULL,
+	rate_limit INTEGER DEFAULT 0,
+	remaining INTEGER DEFAULT 0,
+	reset_at TEXT DEFAULT '',
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (endpoint, token_key)
+);
+
+CREATE TABLE IF NOT EXISTS fetch_jobs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	endpoint TEXT NOT NULL,
+	account_user_id TEXT NOT NULL,
+	target_id TEXT NOT NULL,
+	pagination_token TEXT DEFAULT '',
+	pages INTEGER DEFAULT 0,
+	items INTEGER DEFAULT 0,
+	last_error TEXT DEFAULT '',
+	started_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	UNIQUE (endpoint, account_user_id, target_id)
+);
+
+CREATE TABLE IF NOT EXISTS fetch_job_users (
+	job_id INTEGER NOT NULL,
+	user_id TEXT NOT NULL,
+	PRIMARY KEY (job_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS api_prices (
+	endpoint TEXT PRIMARY KEY,
+	unit_cost REAL NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_calls (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	endpoint TEXT NOT NULL,
+	account_user_id TEXT DEFAULT '',
+	token_key TEXT DEFAULT '',
+	status_code INTEGER,
+	resources INTEGER DEFAULT 0,
+	cost REAL DEFAULT 0,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_calls_created ON api_calls(created_at);
+CREATE INDEX IF NOT EXISTS idx_api_calls_account ON api_calls(account_user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS budgets (
+	scope TEXT PRIMARY KEY,
+	monthly_limit REAL NOT NULL,
+	truncate INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS follow_actions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_user_id TEXT NOT NULL,
+	target_user_id TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT DEFAULT '',
+	created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_follow_actions_account ON follow_actions(account_user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS follow_queue (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_user_id TEXT NOT NULL,
+	target_user_id TEXT NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER DEFAULT 0,
+	last_error TEXT DEFAULT '',
+	added_at TEXT NOT NULL,
+	processed_at TEXT DEFAULT '',
+	UNIQUE(account_user_id, target_user_id)
+);
+
+CREATE TABLE IF NOT EXISTS oauth1_tokens (
+	account_user_id TEXT PRIMARY KEY,
+	access_token TEXT NOT NULL,
+	access_secret TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS oauth2_tokens (
+	account_user_id TEXT PRIMARY KEY,
+	access_token TEXT NOT NULL,
+	refresh_token TEXT NOT NULL DEFAULT '',
+	expires_at TEXT NOT NULL DEFAULT '',
+	scope TEXT NOT NULL DEFAULT '',
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS seed_accounts (
+	account_user_id TEXT NOT NULL,
+	seed_user_id TEXT NOT NULL,
+	added_at TEXT NOT NULL,
+	PRIMARY KEY (account_user_id, seed_user_id)
+);
+
+CREATE TABLE IF NOT EXISTS tweets (
+	id TEXT PRIMARY KEY,
+	author_id TEXT NOT NULL,
+	text TEXT,
+	created_at TEXT NOT NULL,
+	like_count INTEGER DEFAULT 0,
+	retweet_count INTEGER DEFAULT 0,
+	reply_count INTEGER DEFAULT 0,
+	quote_count INTEGER DEFAULT 0,
+	impression_count INTEGER DEFAULT 0,
+	fetched_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tweets_author ON tweets(author_id, created_at);
+
+CREATE TABLE IF NOT EXISTS content_scores (
+	user_id TEXT PRIMARY KEY,
+	tweets INTEGER NOT NULL,
+	posts_per_week REAL NOT NULL,
+	cadence_cv REAL NOT NULL,
+	engagement_rate REAL NOT NULL,
+	engagement_cv REAL NOT NULL,
+	score REAL NOT NULL,
+	computed_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_metrics_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id TEXT NOT NULL,
+	followers_count INTEGER,
+	following_count INTEGER,
+	tweet_count INTEGER,
+	listed_count INTEGER,
+	recorded_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_metrics_history_user ON user_metrics_history(user_id, recorded_at);
+
+CREATE TABLE IF NOT EXISTS follow_schedule (
+	account_user_id TEXT PRIMARY KEY,
+	daily_limit INTEGER NOT NULL,
+	next_at TEXT DEFAULT ''
+);
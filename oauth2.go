@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	oauth2AuthorizeURL = "https://x.com/i/oauth2/authorize"
+
+	// Must match a callback URL registered for the app in the developer portal.
+	defaultOAuth2RedirectURL = "http://127.0.0.1:8976/callback"
+	oauth2Scopes             = "tweet.read users.read follows.read follows.write list.read offline.access"
+
+	// Access tokens are refreshed this long before they expire.
+	oauth2RefreshMargin = 10 * time.Minute
+)
+
+// oauth2LoginTimeout is how long the loopback server waits for the browser
+// to come back. A var so tests can shorten it.
+var oauth2LoginTimeout = 5 * time.Minute
+
+// userContextEndpoints cannot be called with the app-only bearer token.
+var userContextEndpoints = map[string]bool{
+	endpointFollow: true,
+}
+
+// OAuth2Config is the app's OAuth 2.0 client, from OAUTH2_CLIENT_ID,
+// OAUTH2_CLIENT_SECRET (confidential clients only) and OAUTH2_REDIRECT_URL.
+type OAuth2Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+func OAuth2ConfigFromConfig(config *Config) OAuth2Config {
+	c := OAuth2Config{
+		ClientID:     config.OAuth2ClientId,
+		ClientSecret: config.OAuth2ClientSecret,
+		RedirectURL:  config.OAuth2RedirectURL,
+	}
+	if c.RedirectURL == "" {
+		c.RedirectURL = defaultOAuth2RedirectURL
+	}
+	return c
+}
+
+// OAuth2Token is a user access token and the refresh token to renew it.
+type OAuth2Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	Scope        string
+}
+
+func (t *OAuth2Token) expiresWithin(d time.Duration) bool {
+	return !t.ExpiresAt.IsZero() && time.Until(t.ExpiresAt) < d
+}
+
+// randomURLString returns n random bytes, base64url encoded.
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// pkceChallenge is the S256 code challenge of verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (c OAuth2Config) authorizationURL(state, challenge string) string {
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.ClientID},
+		"redirect_uri":          {c.RedirectURL},
+		"scope":                 {oauth2Scopes},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	return oauth2AuthorizeURL + "?" + q.Encode()
+}
+
+// tokenResponse is the body of the token endpoint, success or error.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (c OAuth2Config) exchange(ctx context.Context, code, verifier string) (*OAuth2Token, error) {
+	return c.tokenRequest(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.RedirectURL},
+		"code_verifier": {verifier},
+	})
+}
+
+func (c OAuth2Config) refresh(ctx context.Context, refreshToken string) (*OAuth2Token, error) {
+	return c.tokenRequest(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c OAuth2Config) tokenRequest(ctx context.Context, form url.Values) (*OAuth2Token, error) {
+	form.Set("client_id", c.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiBaseURL+"/2/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("token endpoint returned %d", res.StatusCode)
+	}
+	if res.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		msg := tr.ErrorDescription
+		if msg == "" {
+			msg = tr.Error
+		}
+		return nil, fmt.Errorf("token endpoint returned %d: %s", res.StatusCode, msg)
+	}
+
+	tok := &OAuth2Token{AccessToken: tr.AccessToken, RefreshToken: tr.RefreshToken, Scope: tr.Scope}
+	if tr.ExpiresIn > 0 {
+		tok.ExpiresAt = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second).UTC()
+	}
+	return tok, nil
+}
+
+// OAuth2Login is a started PKCE login. A loopback server on the redirect
+// URL receives the authorization code and exchanges it for tokens.
+type OAuth2Login struct {
+	AccountUserID string
+	authURL       string
+	done          chan struct{}
+	result        oauth2Result
+	finish        func(oauth2Result)
+	srv           *http.Server
+	ln            net.Listener
+}
+
+type oauth2Result struct {
+	token *OAuth2Token
+	err   error
+}
+
+// StartOAuth2PKCELogin starts the loopback server and returns the login;
+// the user opens AuthorizationURL and Wait returns the tokens.
+func StartOAuth2PKCELogin(config OAuth2Config, accountUserID string) (*OAuth2Login, error) {
+	if config.ClientID == "" {
+		return nil, errors.New("OAUTH2_CLIENT_ID must be set for OAuth2 login")
+	}
+	redirect, err := url.Parse(config.RedirectURL)
+	if err != nil || redirect.Scheme != "http" {
+		return nil, fmt.Errorf("invalid OAuth2 redirect URL %q", config.RedirectURL)
+	}
+	if host := redirect.Hostname(); !isLoopbackHost(host) {
+		return nil, fmt.Errorf("OAuth2 redirect URL must point to a loopback address, got %q", host)
+	}
+	callbackPath := redirect.Path
+	if callbackPath == "" {
+		callbackPath = "/"
+	}
+
+	verifier, err := randomURLString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomURLString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return nil, fmt.Errorf("starting callback server: %w", err)
+	}
+
+	login := &OAuth2Login{
+		AccountUserID: accountUserID,
+		authURL:       config.authorizationURL(state, pkceChallenge(verifier)),
+		done:          make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oauth2LoginTimeout)
+	var once sync.Once
+	finish := func(r oauth2Result) {
+		once.Do(func() {
+			login.result = r
+			close(login.done)
+		})
+	}
+
+	// Only the first valid callback is exchanged; a reload must not redeem
+	// the code again.
+	var handled sync.Once
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "Login state mismatch, start the login again.", http.StatusBadRequest)
+			return
+		}
+		first := false
+		handled.Do(func() { first = true })
+		switch {
+		case !first:
+			fmt.Fprintln(w, "XBoost: this login was already completed, return to the app.")
+			return
+		case q.Get("error") != "":
+			finish(oauth2Result{err: fmt.Errorf("authorization denied: %s", q.Get("error"))})
+		default:
+			tok, err := config.exchange(r.Context(), q.Get("code"), verifier)
+			finish(oauth2Result{token: tok, err: err})
+		}
+		fmt.Fprintln(w, "XBoost: you can close this window and return to the app.")
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	login.finish, login.srv, login.ln = finish, srv, ln
+
+	go srv.Serve(ln)
+	go func() {
+		defer cancel()
+		select {
+		case <-ctx.Done():
+			// Free the address before Wait reports the timeout.
+			srv.Close()
+			ln.Close()
+			finish(oauth2Result{err: errors.New("OAuth2 login timed out")})
+		case <-login.done:
+			// Let the browser receive the response before closing.
+			time.Sleep(time.Second)
+			srv.Close()
+		}
+	}()
+
+	return login, nil
+}
+
+func (l *OAuth2Login) AuthorizationURL() string {
+	return l.authURL
+}
+
+// Cancel abandons the login and frees the callback address right away.
+func (l *OAuth2Login) Cancel() {
+	l.finish(oauth2Result{err: errors.New("OAuth2 login was cancelled")})
+	l.srv.Close()
+	// Serve may not have picked the listener up yet.
+	l.ln.Close()
+}
+
+// Wait blocks until the callback arrived, the login timed out or ctx is done.
+func (l *OAuth2Login) Wait(ctx context.Context) (*OAuth2Token, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return l.result.token, l.result.err
+	}
+}
+
+// UserTokenStore hands out OAuth2 user access tokens per account from
+// oauth2_tokens, refreshing them shortly before they expire.
+type UserTokenStore struct {
+	mu     sync.Mutex
+	db     *sql.DB
+	config OAuth2Config
+}
+
+var userTokens = &UserTokenStore{}
+
+func (s *UserTokenStore) Configure(db *sql.DB, config OAuth2Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.db, s.config = db, config
+}
+
+// Token returns a valid access token for the account, false if it has none.
+func (s *UserTokenStore) Token(ctx context.Context, accountUserID string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db == nil || accountUserID == "" {
+		return "", false, nil
+	}
+
+	tok, err := GetOAuth2Token(s.db, accountUserID)
+	if err != nil {
+		return "", false, nil
+	}
+	if tok.expiresWithin(oauth2RefreshMargin) {
+		if tok, err = s.refreshLocked(ctx, accountUserID, tok); err != nil {
+			return "", false, err
+		}
+	}
+	return tok.AccessToken, true, nil
+}
+
+// RefreshExpiring refreshes every stored token expiring within d.
+func (s *UserTokenStore) RefreshExpiring(ctx context.Context, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db == nil {
+		return
+	}
+
+	accounts, err := GetOAuth2TokenAccounts(s.db)
+	if err != nil {
+		log.Printf("[oauth2] Error listing tokens: %v", err)
+		return
+	}
+	for _, accountUserID := range accounts {
+		tok, err := GetOAuth2Token(s.db, accountUserID)
+		if err != nil || !tok.expiresWithin(d) {
+			continue
+		}
+		if _, err := s.refreshLocked(ctx, accountUserID, tok); err != nil {
+			log.Printf("[oauth2] Error refreshing token for account %s: %v", accountUserID, err)
+		}
+	}
+}
+
+func (s *UserTokenStore) refreshLocked(ctx context.Context, accountUserID string, tok *OAuth2Token) (*OAuth2Token, error) {
+	if tok.RefreshToken == "" {
+		return nil, errors.New("OAuth2 token expired and has no refresh token, log in again")
+	}
+	fresh, err := s.config.refresh(ctx, tok.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("refreshing OAuth2 token: %w", err)
+	}
+	// X rotates refresh tokens; keep the old one if none came back.
+	if fresh.RefreshToken == "" {
+		fresh.RefreshToken = tok.RefreshToken
+	}
+	if err := SaveOAuth2Token(s.db, accountUserID, fresh); err != nil {
+		return nil, fmt.Errorf("saving refreshed token: %w", err)
+	}
+	log.Printf("[oauth2] Refreshed token for account %s", accountUserID)
+	return fresh, nil
+}
+
+// userContextEditor replaces the app bearer token with the account's OAuth2
+// user token on endpoints that need user context, when one is stored.
+func userContextEditor(accountUserID string) func(ctx context.Context, req *http.Request) error {
+	return func(ctx context.Context, req *http.Request) error {
+		if !userContextEndpoints[normalizeEndpoint(req.Method, req.URL.Path)] {
+			return nil
+		}
+		token, ok, err := userTokens.Token(ctx, accountUserID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return nil
+	}
+}
+
+const tokenRefreshInterval = 15 * time.Minute
+
+// runTokenRefresh keeps stored OAuth2 tokens fresh until ctx is done, so
+// scheduled follows never start with an expired token.
+func (a *App) runTokenRefresh(ctx context.Context) {
+	ticker := time.NewTicker(tokenRefreshInterval)
+	defer ticker.Stop()
+	for {
+		userTokens.RefreshExpiring(ctx, tokenRefreshInterval+oauth2RefreshMargin)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oauth2TestApp returns a test App whose OAuth2 callback listens on a free
+// loopback port and whose token exchange goes to the fake.
+func oauth2TestApp(t *testing.T) (*App, *FakeXAPI) {
+	t.Helper()
+	a, fake := newTestApp(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	a.config = &Config{OAuth2ClientId: "client", OAuth2RedirectURL: "http://" + addr + "/callback"}
+	return a, fake
+}
+
+func TestOAuth2LoginStoresTokenFromCallback(t *testing.T) {
+	a, fake := oauth2TestApp(t)
+	authURL, err := a.StartOAuth2Login(fakeAccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+
+	errc := make(chan error, 1)
+	go func() { errc <- a.WaitOAuth2Login() }()
+	// A reload of the callback page must not redeem the code twice.
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(q.Get("redirect_uri") + "?code=fixture&state=" + url.QueryEscape(q.Get("state")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Requests(); got != 1 {
+		t.Errorf("fake saw %d token requests, want 1", got)
+	}
+
+	tok, err := GetOAuth2Token(a.db, fakeAccountID)
+	if err != nil || !strings.HasPrefix(tok.AccessToken, "fake-access-") {
+		t.Errorf("stored token %+v (%v), want the fake's access token", tok, err)
+	}
+}
+
+func TestOAuth2LoginReplacesAbandonedLogin(t *testing.T) {
+	a, _ := oauth2TestApp(t)
+	if _, err := a.StartOAuth2Login(fakeAccountID); err != nil {
+		t.Fatal(err)
+	}
+	abandoned := a.pendingOAuth2
+
+	// Same callback address: the first listener must be gone.
+	if _, err := a.StartOAuth2Login(fakeAccountID); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if _, err := abandoned.Wait(a.ctx); err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("abandoned login ended with %v, want cancelled", err)
+	}
+	a.pendingOAuth2.Cancel()
+}
+
+func TestOAuth2LoginTimesOut(t *testing.T) {
+	timeout := oauth2LoginTimeout
+	oauth2LoginTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { oauth2LoginTimeout = timeout })
+
+	a, _ := oauth2TestApp(t)
+	if _, err := a.StartOAuth2Login(fakeAccountID); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.WaitOAuth2Login(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if a.pendingOAuth2 != nil {
+		t.Error("timed-out login is still pending")
+	}
+	if _, err := a.StartOAuth2Login(fakeAccountID); err != nil {
+		t.Fatalf("login after a timeout: %v", err)
+	}
+	a.pendingOAuth2.Cancel()
+}
+
+func TestUserContextCallsUseTheUserTokenKey(t *testing.T) {
+	a, fake := newTestApp(t)
+	fake.RateLimit = 10 // sends x-rate-limit-* headers
+	userToken := "user-token-" + t.Name()
+	useOAuth2Token(t, a, userToken)
+
+	if _, err := a.FollowUser(fakeUserID(250)); err != nil {
+		t.Fatal(err)
+	}
+	var key string
+	if err := a.db.QueryRow(`SELECT token_key FROM api_calls WHERE endpoint = ?`, endpointFollow).Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+	if key != tokenFingerprint(userToken) {
+		t.Errorf("follow charged to %q, want the user token %q", key, tokenFingerprint(userToken))
+	}
+
+	var tracked bool
+	for _, rl := range rateLimits.Snapshot() {
+		if rl.Endpoint != endpointFollow {
+			continue
+		}
+		if rl.TokenKey == tokenFingerprint(testAccount(t, a).BearerToken) {
+			t.Error("follow rate limit tracked under the app bearer token")
+		}
+		tracked = tracked || rl.TokenKey == tokenFingerprint(userToken)
+	}
+	if !tracked {
+		t.Error("follow rate limit not tracked under the user token")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Page is a single page of results returned by a paginated X API endpoint.
+type Page[T any] struct {
+	Items     []T
+	NextToken *string
+}
+
+// PageFetcher fetches one page starting at paginationToken (nil for the first
+// page) with at most maxResults items (0 means the endpoint default).
+type PageFetcher[T any] func(ctx context.Context, paginationToken *string, maxResults int) (Page[T], error)
+
+// Paginator walks a next_token paginated endpoint with a fixed delay between
+// requests. MaxPages and MaxItems cap the walk (0 means unlimited). X bills
+// every resource a page returns, so under MaxItems the last page is requested
+// with a smaller max_results; MinPageSize is the smallest the endpoint accepts.
+type Paginator[T any] struct {
+	Fetch       PageFetcher[T]
+	Label       string
+	Interval    time.Duration
+	MaxPages    int
+	MaxItems    int
+	MinPageSize int
+}
+
+// PaginationResult summarises a finished walk. NextToken is non-nil when the
+// walk stopped before the last page (cap reached), so it can be resumed later.
+type PaginationResult struct {
+	Pages     int
+	Items     int
+	NextToken *string
+}
+
+func NewPaginator[T any](label string, fetch PageFetcher[T]) *Paginator[T] {
+	return &Paginator[T]{
+		Fetch:    fetch,
+		Label:    label,
+		Interval: rate_limit,
+	}
+}
+
+// Each fetches pages starting at startToken and hands every page to onPage as
+// it arrives. The first request is sent immediately, later ones wait Interval.
+// Caps are checked between pages: a page is never cut, since dropped users
+// would sit behind the saved resume cursor and never be fetched.
+func (p *Paginator[T]) Each(ctx context.Context, startToken *string, onPage func(Page[T]) error) (PaginationResult, error) {
+	var result PaginationResult
+	token := startToken
+
+	if _, ok := p.pageSize(0); !ok {
+		result.NextToken = token
+		return result, fmt.Errorf("%s: cap of %d items is below the minimum page of %d", p.Label, p.MaxItems, p.MinPageSize)
+	}
+
+	for {
+		size, _ := p.pageSize(result.Items)
+		if result.Pages > 0 {
+			timer := time.NewTimer(p.Interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				result.NextToken = token
+				return result, ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			result.NextToken = token
+			return result, err
+		}
+
+		page, err := p.Fetch(ctx, token, size)
+		if err != nil {
+			result.NextToken = token
+			return result, err
+		}
+
+		result.Pages++
+		result.Items += len(page.Items)
+		log.Printf("Counting %s: %d", p.Label, result.Items)
+
+		if onPage != nil {
+			if err := onPage(page); err != nil {
+				result.NextToken = token
+				return result, err
+			}
+		}
+
+		token = page.NextToken
+		if token == nil {
+			return result, nil
+		}
+		if _, ok := p.pageSize(result.Items); !ok || (p.MaxPages > 0 && result.Pages >= p.MaxPages) {
+			result.NextToken = token
+			log.Printf("[fetch] %s: stopping after %d pages / %d items (cap reached)", p.Label, result.Pages, result.Items)
+			return result, nil
+		}
+	}
+}
+
+// pageSize is the max_results of the next page once items were fetched: 0
+// (endpoint default) when uncapped or a full page still fits under MaxItems,
+// otherwise what is left. ok is false when less than one page is left.
+func (p *Paginator[T]) pageSize(items int) (size int, ok bool) {
+	if p.MaxItems <= 0 {
+		return 0, true
+	}
+	left := p.MaxItems - items
+	if left < max(p.MinPageSize, 1) {
+		return 0, false
+	}
+	if left >= defaultPageSize {
+		return 0, true
+	}
+	return left, true
+}
+
+// All collects every item into memory. Prefer Each for large lists.
+func (p *Paginator[T]) All(ctx context.Context) ([]T, error) {
+	all := make([]T, 0)
+	_, err := p.Each(ctx, nil, func(page Page[T]) error {
+		all = append(all, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
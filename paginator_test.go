@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-twitter-follower/gen"
+)
+
+func TestPaginatorResumesFromNextToken(t *testing.T) {
+	a, fake := newTestApp(t)
+	client, err := NewAccountClient(testAccount(t, a))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	collect := func(page Page[gen.User]) error {
+		for _, u := range page.Items {
+			seen[u.Id] = true
+		}
+		return nil
+	}
+
+	p := NewFollowingPaginator(client, fakeAccountID)
+	p.MaxPages = 1
+	first, err := p.Each(context.Background(), nil, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Pages != 1 || first.Items != 100 || first.NextToken == nil {
+		t.Fatalf("first walk = %+v, want 1 page of 100 and a next token", first)
+	}
+
+	p.MaxPages = 0
+	rest, err := p.Each(context.Background(), first.NextToken, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rest.Items != 120 || rest.NextToken != nil {
+		t.Fatalf("resumed walk = %+v, want the remaining 120 and no next token", rest)
+	}
+	if len(seen) != 220 {
+		t.Errorf("got %d distinct users, want 220", len(seen))
+	}
+	if got := fake.Requests(); got != 3 {
+		t.Errorf("fake saw %d requests, want 3", got)
+	}
+}
+
+func TestFetchFollowingResumesPendingJob(t *testing.T) {
+	a, fake := newTestApp(t)
+	acct := testAccount(t, a)
+	client, err := NewAccountClient(acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A first run that stops after one page leaves the job pending.
+	p := NewFollowingPaginator(client, acct.UserID)
+	p.MaxPages = 1
+	if _, complete, err := a.runFetchJob(context.Background(), endpointFollowing, acct.UserID, acct.UserID, p); err != nil || complete {
+		t.Fatalf("first run: complete=%v err=%v, want a pending job", complete, err)
+	}
+	job, err := GetFetchJob(a.db, endpointFollowing, acct.UserID, acct.UserID)
+	if err != nil || job.Pages != 1 || job.PaginationToken != "100" {
+		t.Fatalf("pending job = %+v (%v), want 1 page with token 100", job, err)
+	}
+
+	if _, err := a.fetchFollowingForAccount(context.Background(), acct); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(a.GetFollowingList()); got != 220 {
+		t.Errorf("following list has %d users, want 220", got)
+	}
+	if got := fake.Requests(); got != 3 {
+		t.Errorf("fake saw %d requests, want 3 (the first page is not fetched again)", got)
+	}
+	if _, err := GetFetchJob(a.db, endpointFollowing, acct.UserID, acct.UserID); err == nil {
+		t.Error("job still exists after the snapshot was committed")
+	}
+}
+
+func TestPaginatorShrinksLastPageToMaxItems(t *testing.T) {
+	a, _ := newTestApp(t)
+	client, err := NewAccountClient(testAccount(t, a))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewFollowingPaginator(client, fakeAccountID)
+	p.MaxItems = 150
+	result, err := p.Each(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Items != 150 || result.Pages != 2 {
+		t.Fatalf("walk = %+v, want 150 items in 2 pages", result)
+	}
+	if result.NextToken == nil || *result.NextToken != "150" {
+		t.Errorf("next token = %v, want 150 so nothing is skipped on resume", result.NextToken)
+	}
+	if got := countRows(t, a, `SELECT COALESCE(SUM(resources), 0) FROM api_calls`); got != 150 {
+		t.Errorf("billed %d resources, want 150", got)
+	}
+}
+
+func TestPaginatorRefusesCapBelowMinimumPage(t *testing.T) {
+	a, fake := newTestApp(t)
+	client, err := NewAccountClient(testAccount(t, a))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewUserTweetsPaginator(client, fakeAccountID)
+	p.MaxItems = 3
+	if _, err := p.Each(context.Background(), nil, nil); err == nil || !strings.Contains(err.Error(), "minimum page") {
+		t.Fatalf("err = %v, want a minimum page error", err)
+	}
+	if got := fake.Requests(); got != 0 {
+		t.Errorf("fake saw %d requests, want none", got)
+	}
+}
+
+func TestEmptyRelationIsEmptyLastPage(t *testing.T) {
+	a, fake := newTestApp(t)
+	client, err := NewAccountClient(testAccount(t, a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The API leaves out data (not just empties it) when there is nothing.
+	fake.mu.Lock()
+	fake.following[fakeAccountID], fake.followers[fakeAccountID] = nil, nil
+	fake.mu.Unlock()
+
+	for name, fetch := range map[string]func(context.Context, *gen.ClientWithResponses, string, *string, int) (*[]gen.User, *string, error){
+		"following": GetFollowing,
+		"followers": GetFollowers,
+	} {
+		users, next, err := fetch(context.Background(), client, fakeAccountID, nil, 0)
+		if err != nil || users == nil || len(*users) != 0 || next != nil {
+			t.Errorf("%s = %v, %v, %v; want an empty last page", name, users, next, err)
+		}
+	}
+}
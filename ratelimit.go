@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-twitter-follower/gen"
+)
+
+const (
+	// Retries for 429 and 5xx responses before giving up on a single page.
+	maxRetries = 5
+	// Upper bound for a single backoff sleep when no reset header is present.
+	backoffMax = 2 * time.Minute
+)
+
+// backoffBase is the base delay for exponential backoff, doubled on every
+// attempt. A var so tests can shorten it.
+var backoffBase = 2 * time.Second
+
+// RateLimit is the last observed x-rate-limit-* state for one endpoint and token.
+type RateLimit struct {
+	Endpoint  string `json:"endpoint"`
+	TokenKey  string `json:"token_key"`
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	ResetAt   string `json:"reset_at"`
+	UpdatedAt string `json:"updated_at"`
+
+	reset time.Time
+}
+
+// RateLimitTracker keeps observed rate limits in memory and, when db is set,
+// mirrors them into the rate_limits table.
+type RateLimitTracker struct {
+	mu     sync.Mutex
+	limits map[string]*RateLimit
+	db     *sql.DB
+}
+
+var rateLimits = &RateLimitTracker{limits: make(map[string]*RateLimit)}
+
+// SetDB enables persistence and restores limits observed in earlier runs,
+// so an exhausted window is still honoured after a restart.
+func (t *RateLimitTracker) SetDB(db *sql.DB) {
+	stored, err := GetRateLimits(db)
+	if err != nil {
+		log.Printf("Warning: failed to load rate limits: %v", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.db = db
+	for i := range stored {
+		rl := stored[i]
+		t.limits[rl.Endpoint+"|"+rl.TokenKey] = &rl
+	}
+}
+
+// Observe records the rate-limit headers of a response, if present.
+func (t *RateLimitTracker) Observe(endpoint, tokenKey string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("x-rate-limit-remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(header.Get("x-rate-limit-limit"))
+	var reset time.Time
+	if epoch, err := strconv.ParseInt(header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+		reset = time.Unix(epoch, 0).UTC()
+	}
+
+	rl := &RateLimit{
+		Endpoint:  endpoint,
+		TokenKey:  tokenKey,
+		Limit:     limit,
+		Remaining: remaining,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		reset:     reset,
+	}
+	if !reset.IsZero() {
+		rl.ResetAt = reset.Format(time.RFC3339)
+	}
+
+	t.mu.Lock()
+	t.limits[endpoint+"|"+tokenKey] = rl
+	db := t.db
+	t.mu.Unlock()
+
+	if db != nil {
+		if err := SaveRateLimit(db, *rl); err != nil {
+			log.Printf("Warning: failed to save rate limit: %v", err)
+		}
+	}
+}
+
+// WaitFor blocks until the window for endpoint/token resets if the last
+// response reported no remaining requests.
+func (t *RateLimitTracker) WaitFor(ctx context.Context, endpoint, tokenKey string) error {
+	t.mu.Lock()
+	rl, ok := t.limits[endpoint+"|"+tokenKey]
+	var reset time.Time
+	if ok && rl.Remaining <= 0 {
+		reset = rl.reset
+	}
+	t.mu.Unlock()
+
+	if reset.IsZero() {
+		return nil
+	}
+	wait := time.Until(reset) + time.Second
+	if wait <= 0 {
+		return nil
+	}
+	log.Printf("[ratelimit] %s exhausted, sleeping %s until reset", endpoint, wait.Round(time.Second))
+	return sleepCtx(ctx, wait)
+}
+
+// Snapshot returns all observed limits sorted by endpoint.
+func (t *RateLimitTracker) Snapshot() []RateLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]RateLimit, 0, len(t.limits))
+	for _, rl := range t.limits {
+		result = append(result, *rl)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Endpoint != result[j].Endpoint {
+			return result[i].Endpoint < result[j].Endpoint
+		}
+		return result[i].TokenKey < result[j].TokenKey
+	})
+	return result
+}
+
+// rateLimitedDoer wraps an HTTP client, honouring x-rate-limit-* headers and
+// retrying 429 responses, and 5xx responses to GET, with jittered exponential
+// backoff. A 5xx to a POST (e.g. a follow) may have been applied already, so
+// it is returned as is.
+type rateLimitedDoer struct {
+	next     gen.HttpRequestDoer
+	tracker  *RateLimitTracker
+	tokenKey string
+}
+
+func newRateLimitedDoer(next gen.HttpRequestDoer, token string) *rateLimitedDoer {
+	return &rateLimitedDoer{
+		next:     next,
+		tracker:  rateLimits,
+		tokenKey: tokenFingerprint(token),
+	}
+}
+
+func (d *rateLimitedDoer) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	endpoint := normalizeEndpoint(req.Method, req.URL.Path)
+	tokenKey := requestTokenKey(req, d.tokenKey)
+
+	for attempt := 0; ; attempt++ {
+		if err := d.tracker.WaitFor(ctx, endpoint, tokenKey); err != nil {
+			return nil, err
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewinding request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		res, err := d.next.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		d.tracker.Observe(endpoint, tokenKey, res.Header)
+
+		retryable := res.StatusCode == http.StatusTooManyRequests ||
+			res.StatusCode >= 500 && (req.Method == http.MethodGet || req.Method == http.MethodHead)
+		if !retryable || attempt >= maxRetries {
+			return res, nil
+		}
+		res.Body.Close()
+
+		wait := backoffDelay(attempt)
+		if res.StatusCode == http.StatusTooManyRequests {
+			if epoch, err := strconv.ParseInt(res.Header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+				if untilReset := time.Until(time.Unix(epoch, 0)) + time.Second; untilReset > wait {
+					wait = untilReset
+				}
+			}
+		}
+		log.Printf("[ratelimit] %s returned HTTP %d, retry %d/%d in %s", endpoint, res.StatusCode, attempt+1, maxRetries, wait.Round(time.Second))
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoffDelay returns base*2^attempt capped at backoffMax, with full jitter
+// over the upper half so concurrent retries spread out.
+func backoffDelay(attempt int) time.Duration {
+	d := backoffBase << attempt
+	if d > backoffMax || d <= 0 {
+		d = backoffMax
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// normalizeEndpoint maps a concrete request path to its route template,
+// e.g. GET /2/users/123/following -> GET /2/users/:id/following.
+func normalizeEndpoint(method, path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if i > 0 && parts[i-1] == "username" {
+			parts[i] = ":username"
+			continue
+		}
+		// parts[1] is the API version ("2"), not an id.
+		if i > 1 && p != "" && strings.Trim(p, "0123456789") == "" {
+			parts[i] = ":id"
+		}
+	}
+	return method + " " + strings.Join(parts, "/")
+}
+
+// requestTokenKey fingerprints the bearer token req carries, which is the
+// account's OAuth2 user token on user-context endpoints, else returns
+// fallback (the client's own token, e.g. under OAuth1 signing).
+func requestTokenKey(req *http.Request, fallback string) string {
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		return tokenFingerprint(token)
+	}
+	return fallback
+}
+
+// tokenFingerprint identifies a token in logs and tables without storing it.
+func tokenFingerprint(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRetriesServerErrorsAndRateLimits(t *testing.T) {
+	a, fake := newTestApp(t)
+	client, err := NewAccountClient(testAccount(t, a))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.FailNext(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	users, next, err := GetFollowing(context.Background(), client, fakeAccountID, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users == nil || len(*users) != 100 || next == nil {
+		t.Fatalf("got %v users, next %v; want the first page of 100", users, next)
+	}
+	if got := fake.Requests(); got != 3 {
+		t.Errorf("fake saw %d requests, want 3", got)
+	}
+	// Every attempt is in the ledger, only the successful one is billed.
+	if got := countRows(t, a, `SELECT COUNT(*) FROM api_calls`); got != 3 {
+		t.Errorf("ledger has %d calls, want 3", got)
+	}
+	if got := countRows(t, a, `SELECT COUNT(*) FROM api_calls WHERE cost > 0`); got != 1 {
+		t.Errorf("ledger billed %d calls, want 1", got)
+	}
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	a, fake := newTestApp(t)
+	client, err := NewAccountClient(testAccount(t, a))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i <= maxRetries; i++ {
+		fake.FailNext(http.StatusInternalServerError)
+	}
+	if _, _, err := GetFollowing(context.Background(), client, fakeAccountID, nil, 0); err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if got := fake.Requests(); got != maxRetries+1 {
+		t.Errorf("fake saw %d requests, want %d", got, maxRetries+1)
+	}
+}
+
+func TestRetriesPostOnlyOnRateLimit(t *testing.T) {
+	a, fake := newTestApp(t)
+	useOAuth2Token(t, a, "user-token")
+
+	// The follow may have gone through before the 503: never send it twice.
+	fake.FailNext(http.StatusServiceUnavailable)
+	if action, err := a.FollowUser(fakeUserID(250)); err == nil || action.Status != followStatusFailed {
+		t.Errorf("follow after a 503: %+v (%v), want failed", action, err)
+	}
+	if got := fake.Requests(); got != 1 {
+		t.Errorf("fake saw %d requests after a 503, want 1", got)
+	}
+
+	fake.FailNext(http.StatusTooManyRequests)
+	if _, err := a.FollowUser(fakeUserID(251)); err != nil {
+		t.Errorf("follow after a 429: %v, want it retried", err)
+	}
+	if got := fake.Requests(); got != 3 {
+		t.Errorf("fake saw %d requests, want 3", got)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+)
+
+// Recommendation is a user followed by one or more seeds but not by the account.
+type Recommendation struct {
+	FollowingUser
+	SeedCount int     `json:"seed_count"`
+	Score     float64 `json:"score"`
+}
+
+// followBackWeight is how much the candidate's following/followers ratio
+// counts next to seed overlap: accounts that follow many people relative to
+// their audience are likelier to follow back.
+const followBackWeight = 0.25
+
+const maxCandidates = 2000
+
+// recommendationScore ranks mostly by the share of seeds following the
+// candidate, nudged by how likely the candidate is to follow back.
+func recommendationScore(seedCount, totalSeeds int, u FollowingUser) float64 {
+	overlap := float64(seedCount) / float64(totalSeeds)
+	ratio := 1.0
+	if u.FollowersCount > 0 {
+		ratio = math.Min(float64(u.FollowingCount)/float64(u.FollowersCount), 1)
+	}
+	return math.Round(((1-followBackWeight)*overlap+followBackWeight*ratio)*1000) / 1000
+}
+
+// BuildRecommendations implements the README algorithm: diff the following
+// lists of the account's seeds against its own following. Candidates must be
+// followed by at least minSeeds seeds; at most limit are returned, best first.
+func BuildRecommendations(db *sql.DB, accountUserId string, minSeeds, limit int) ([]Recommendation, error) {
+	seeds, err := GetSeeds(db, accountUserId)
+	if err != nil {
+		return nil, err
+	}
+	var seedIds []string
+	for _, s := range seeds {
+		if s.FetchedAt != "" {
+			seedIds = append(seedIds, s.UserID)
+		}
+	}
+	if len(seedIds) == 0 {
+		return nil, nil
+	}
+
+	counts, err := CountSeedFollowing(db, seedIds)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := map[string]bool{accountUserId: true}
+	for _, s := range seeds {
+		exclude[s.UserID] = true
+	}
+	following, err := GetLatestSnapshotIDs(db, "following_snapshots", accountUserId)
+	if err != nil {
+		return nil, err
+	}
+	followed, err := GetFollowedTargetIDs(db, accountUserId)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range append(following, followed...) {
+		exclude[id] = true
+	}
+
+	var ids []string
+	for id, n := range counts {
+		if n >= minSeeds && !exclude[id] {
+			ids = append(ids, id)
+		}
+	}
+	// Seed overlap dominates the score, so only the most-shared candidates
+	// need a user lookup.
+	if len(ids) > maxCandidates {
+		sort.Slice(ids, func(i, j int) bool { return counts[ids[i]] > counts[ids[j]] })
+		ids = ids[:maxCandidates]
+	}
+
+	users, err := GetUsersByIDs(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]Recommendation, 0, len(users))
+	for _, u := range users {
+		n := counts[u.Id]
+		recs = append(recs, Recommendation{
+			FollowingUser: u,
+			SeedCount:     n,
+			Score:         recommendationScore(n, len(seedIds), u),
+		})
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].FollowersCount > recs[j].FollowersCount
+	})
+	if limit > 0 && len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs, nil
+}
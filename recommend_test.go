@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildRecommendations(t *testing.T) {
+	db := newTestDB(t)
+	const acct = "1"
+	for _, id := range []string{"c1", "c2", "c3"} {
+		if _, err := db.Exec(`INSERT INTO users (id, username, followers_count, following_count) VALUES (?, ?, 100, 50)`, id, "user_"+id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, seed := range []string{"s1", "s2", "s3", "s4"} {
+		if err := AddSeed(db, acct, seed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// s4 is never fetched and does not count towards the total.
+	insertSnapshot(t, db, "following_snapshots", "s1", "2026-01-01T00:00:00Z", "c1", "c2", "x")
+	insertSnapshot(t, db, "following_snapshots", "s2", "2026-01-01T00:00:00Z", "c1", "c2", "s3")
+	insertSnapshot(t, db, "following_snapshots", "s3", "2026-01-01T00:00:00Z", "c3")
+	insertSnapshot(t, db, "following_snapshots", "s3", "2026-01-02T00:00:00Z", "c1")
+	insertSnapshot(t, db, "following_snapshots", acct, "2026-01-01T00:00:00Z", "x")
+
+	recs, err := BuildRecommendations(db, acct, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[0].Id != "c1" || recs[1].Id != "c2" {
+		t.Fatalf("recommendations = %+v, want c1 then c2", recs)
+	}
+	if recs[0].SeedCount != 3 || recs[1].SeedCount != 2 {
+		t.Errorf("seed counts %d and %d, want 3 and 2", recs[0].SeedCount, recs[1].SeedCount)
+	}
+	// 3 of 3 seeds and a following/followers ratio of 0.5.
+	if want := 0.75 + 0.25*0.5; recs[0].Score != want {
+		t.Errorf("c1 score %v, want %v", recs[0].Score, want)
+	}
+
+	recs, err = BuildRecommendations(db, acct, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Id != "c1" {
+		t.Errorf("limit 1 = %+v, want only c1", recs)
+	}
+}
+
+func TestBuildRecommendationsWithoutFetchedSeeds(t *testing.T) {
+	db := newTestDB(t)
+	if err := AddSeed(db, "1", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := BuildRecommendations(db, "1", 1, 10)
+	if err != nil || len(recs) != 0 {
+		t.Errorf("recommendations = %+v (%v), want none", recs, err)
+	}
+}
+
+func TestFetchSeedsNowSkipsSeedsThatDoNotFitTheBudget(t *testing.T) {
+	a, fake := newTestApp(t)
+	for _, seed := range []string{fakeUserID(0), fakeUserID(1)} {
+		if _, err := a.db.Exec(`INSERT INTO users (id, username, following_count) VALUES (?, ?, 60)`, seed, "seed_"+seed); err != nil {
+			t.Fatal(err)
+		}
+		if err := AddSeed(a.db, fakeAccountID, seed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Room for the first seed's 60 followings and 40 of the second's.
+	price := GetUnitPrice(a.db, endpointFollowing)
+	if err := SetBudget(a.db, Budget{MonthlyLimit: 100 * price, Truncate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := a.FetchSeedsNow()
+	if !strings.Contains(msg, "Fetched 1 seeds") || !strings.Contains(msg, "skipped") {
+		t.Errorf("message %q, want one seed fetched and one skipped", msg)
+	}
+	if got := fake.Requests(); got != 1 {
+		t.Errorf("fake saw %d requests, want 1", got)
+	}
+	if got := countRows(t, a, `SELECT COUNT(DISTINCT source_user_id) FROM following_snapshots`); got != 1 {
+		t.Errorf("%d seeds have snapshots, want 1", got)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// RelationshipBuckets splits everyone in the latest following and followers
+// snapshots of an account into three groups.
+type RelationshipBuckets struct {
+	MutualCount           int             `json:"mutual_count"`
+	NotFollowingBackCount int             `json:"not_following_back_count"`
+	FansCount             int             `json:"fans_count"`
+	Mutual                []FollowingUser `json:"mutual"`
+	NotFollowingBack      []FollowingUser `json:"not_following_back"`
+	Fans                  []FollowingUser `json:"fans"`
+}
+
+// ClassifyRelationships buckets users as mutual (both ways), not following
+// back (we follow them only) and fans (they follow us only).
+func ClassifyRelationships(db *sql.DB, sourceUserId string) (RelationshipBuckets, error) {
+	var b RelationshipBuckets
+
+	following, err := GetLatestSnapshotIDs(db, "following_snapshots", sourceUserId)
+	if err != nil {
+		return b, err
+	}
+	followers, err := GetLatestSnapshotIDs(db, "followers_snapshots", sourceUserId)
+	if err != nil {
+		return b, err
+	}
+
+	isFollower := make(map[string]bool, len(followers))
+	for _, id := range followers {
+		isFollower[id] = true
+	}
+	isFollowing := make(map[string]bool, len(following))
+
+	var mutual, notBack, fans []string
+	for _, id := range following {
+		isFollowing[id] = true
+		if isFollower[id] {
+			mutual = append(mutual, id)
+		} else {
+			notBack = append(notBack, id)
+		}
+	}
+	for _, id := range followers {
+		if !isFollowing[id] {
+			fans = append(fans, id)
+		}
+	}
+
+	if b.Mutual, err = GetUsersByIDs(db, mutual); err != nil {
+		return b, err
+	}
+	if b.NotFollowingBack, err = GetUsersByIDs(db, notBack); err != nil {
+		return b, err
+	}
+	if b.Fans, err = GetUsersByIDs(db, fans); err != nil {
+		return b, err
+	}
+	// Count from the snapshots: users without a users row are listed nowhere
+	// but still belong to their bucket.
+	b.MutualCount = len(mutual)
+	b.NotFollowingBackCount = len(notBack)
+	b.FansCount = len(fans)
+	return b, nil
+}
+
+// sortUsers sorts in place by a FollowingUser JSON field name; unknown fields
+// fall back to followers_count. dir is "asc" or "desc".
+func sortUsers(users []FollowingUser, field, dir string) {
+	less := func(i, j int) bool { return users[i].FollowersCount < users[j].FollowersCount }
+	switch field {
+	case "following_count":
+		less = func(i, j int) bool { return users[i].FollowingCount < users[j].FollowingCount }
+	case "tweet_count":
+		less = func(i, j int) bool { return users[i].TweetCount < users[j].TweetCount }
+	case "listed_count":
+		less = func(i, j int) bool { return users[i].ListedCount < users[j].ListedCount }
+	case "stability_score":
+		less = func(i, j int) bool { return users[i].StabilityScore < users[j].StabilityScore }
+	case "username":
+		less = func(i, j int) bool { return strings.ToLower(users[i].Username) < strings.ToLower(users[j].Username) }
+	case "name":
+		less = func(i, j int) bool { return strings.ToLower(users[i].Name) < strings.ToLower(users[j].Name) }
+	}
+
+	if dir == "asc" {
+		sort.SliceStable(users, less)
+	} else {
+		sort.SliceStable(users, func(i, j int) bool { return less(j, i) })
+	}
+}
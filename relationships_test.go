@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestClassifyRelationships(t *testing.T) {
+	db := newTestDB(t)
+	const acct = "1"
+	insertSnapshot(t, db, "following_snapshots", acct, "2026-01-01T00:00:00Z", "gone")
+	insertSnapshot(t, db, "following_snapshots", acct, "2026-01-02T00:00:00Z", "m1", "m2", "n1")
+	insertSnapshot(t, db, "followers_snapshots", acct, "2026-01-02T00:00:00Z", "m1", "m2", "f1")
+	// A fan the users table knows nothing about.
+	if _, err := db.Exec(`INSERT INTO followers_snapshots (source_user_id, target_user_id, fetched_at) VALUES (?, 'f2', '2026-01-02T00:00:00Z')`, acct); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ClassifyRelationships(db, acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.MutualCount != 2 || b.NotFollowingBackCount != 1 || b.FansCount != 2 {
+		t.Errorf("counts %d/%d/%d, want 2/1/2", b.MutualCount, b.NotFollowingBackCount, b.FansCount)
+	}
+	if len(b.NotFollowingBack) != 1 || b.NotFollowingBack[0].Id != "n1" {
+		t.Errorf("not following back = %+v, want n1 only", b.NotFollowingBack)
+	}
+	if len(b.Fans) != 1 || b.Fans[0].Id != "f1" {
+		t.Errorf("fans = %+v, want f1 (f2 has no users row)", b.Fans)
+	}
+}
+
+func TestSortUsers(t *testing.T) {
+	users := []FollowingUser{
+		{Id: "a", Username: "bob", FollowersCount: 5, FollowingCount: 1},
+		{Id: "b", Username: "Alice", FollowersCount: 9, FollowingCount: 3},
+		{Id: "c", Username: "carol", FollowersCount: 1, FollowingCount: 2},
+	}
+	tests := []struct {
+		field, dir string
+		want       string
+	}{
+		{"username", "asc", "bac"},
+		{"following_count", "desc", "bca"},
+		{"unknown", "desc", "bac"},
+		{"followers_count", "asc", "cab"},
+	}
+	for _, tt := range tests {
+		sortUsers(users, tt.field, tt.dir)
+		var got string
+		for _, u := range users {
+			got += u.Id
+		}
+		if got != tt.want {
+			t.Errorf("sort by %s %s = %s, want %s", tt.field, tt.dir, got, tt.want)
+		}
+	}
+}
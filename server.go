@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultServeAddr = "127.0.0.1:8787"
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// apiServer exposes the bound App methods as a local JSON REST API. Each
+// request acts on the account named by ?account= (default: the startup
+// account) without touching the App's selected account. Every request must
+// carry the per-run token as "Authorization: Bearer <token>".
+type apiServer struct {
+	app            *App
+	defaultAccount string
+	token          string
+}
+
+func newAPIServer(a *App) (*apiServer, error) {
+	token, err := randomURLString(32)
+	if err != nil {
+		return nil, fmt.Errorf("generating API token: %w", err)
+	}
+	return &apiServer{app: a, defaultAccount: a.selectedAccountID, token: token}, nil
+}
+
+// isLoopbackHost reports whether host (without port) is localhost or a
+// loopback IP.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/accounts", s.handleAccounts)
+	mux.HandleFunc("GET /api/following", s.withAccount(s.handleFollowing))
+	mux.HandleFunc("GET /api/followers", s.withAccount(s.handleFollowers))
+	mux.HandleFunc("GET /api/lists", s.withAccount(s.handleLists))
+	mux.HandleFunc("GET /api/lists/{id}/members", s.withAccount(s.handleListMembers))
+	mux.HandleFunc("GET /api/stats", s.withAccount(s.handleStats))
+	mux.HandleFunc("GET /api/export", s.handleExport)
+	mux.HandleFunc("POST /api/fetch/{target}", s.withAccount(s.handleFetch))
+	return s.authorize(mux)
+}
+
+// authorize rejects requests without the per-run token, and requests to a
+// loopback listener whose Host is not loopback (DNS rebinding).
+func (s *apiServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hostAllowed(r) {
+			writeAPIError(w, http.StatusForbidden, fmt.Errorf("host %q not allowed", r.Host))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Serve listens on addr until ctx is done.
+func (s *apiServer) Serve(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("[serve] Listening on http://%s/api", addr)
+	// The token goes to stderr once, never through the log; stdout is kept
+	// for the command's JSON result.
+	fmt.Fprintf(os.Stderr, "Send \"Authorization: Bearer %s\" with every request to http://%s/api\n", s.token, addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// apiErrorBody is the body of every non-2xx response.
+type apiErrorBody struct {
+	Error string `json:"error"`
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, apiErrorBody{Error: err.Error()})
+}
+
+// accountHandler serves a request on behalf of acct.
+type accountHandler func(w http.ResponseWriter, r *http.Request, acct Account)
+
+// hostAllowed reports whether r names a loopback Host when it arrived on a
+// loopback listener; -allow-remote listeners accept any Host.
+func hostAllowed(r *http.Request) bool {
+	local, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return true
+	}
+	if ip, _, err := net.SplitHostPort(local.String()); err != nil || !isLoopbackHost(ip) {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	return err == nil && isLoopbackHost(host)
+}
+
+// withAccount resolves the request's account and passes it to h.
+func (s *apiServer) withAccount(h accountHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var acct *Account
+		var err error
+		if name := r.URL.Query().Get("account"); name != "" {
+			acct, err = findAccount(s.app.db, name)
+		} else if s.defaultAccount != "" {
+			acct, err = GetAccountByUserID(s.app.db, s.defaultAccount)
+		} else {
+			err = errors.New("no account configured")
+		}
+		if err != nil {
+			writeAPIError(w, http.StatusNotFound, err)
+			return
+		}
+		h(w, r, *acct)
+	}
+}
+
+func (s *apiServer) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts := s.app.GetAccounts()
+	if accounts == nil {
+		accounts = []Account{}
+	}
+	writeAPIJSON(w, http.StatusOK, accounts)
+}
+
+func (s *apiServer) handleFollowing(w http.ResponseWriter, r *http.Request, acct Account) {
+	s.writeUserPage(w, r, s.app.followingList(acct.UserID))
+}
+
+func (s *apiServer) handleFollowers(w http.ResponseWriter, r *http.Request, acct Account) {
+	s.writeUserPage(w, r, s.app.followersList(acct.UserID))
+}
+
+func (s *apiServer) handleListMembers(w http.ResponseWriter, r *http.Request, acct Account) {
+	s.writeUserPage(w, r, s.app.getListMembersFromCache(acct.UserID, r.PathValue("id")))
+}
+
+func (s *apiServer) handleLists(w http.ResponseWriter, r *http.Request, acct Account) {
+	lists := GetCachedLists(s.app.db, acct.UserID)
+	if lists == nil {
+		lists = []TwitterList{}
+	}
+	writeAPIJSON(w, http.StatusOK, lists)
+}
+
+// statsResponse groups the stats the UI shows per tab.
+type statsResponse struct {
+	Following Stats `json:"following"`
+	Followers Stats `json:"followers"`
+	Lists     Stats `json:"lists"`
+}
+
+func (s *apiServer) handleStats(w http.ResponseWriter, r *http.Request, acct Account) {
+	writeAPIJSON(w, http.StatusOK, statsResponse{
+		Following: s.app.followingStats(acct.UserID),
+		Followers: s.app.followersStats(acct.UserID),
+		Lists:     s.app.listsStats(acct.UserID),
+	})
+}
+
+// handleExport takes account, from, to, tables (comma-separated) and
+// credentials like `xboost export`; tokens are redacted by default and never
+// served in plain text.
+func (s *apiServer) handleExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := ExportOptions{From: q.Get("from"), To: q.Get("to"), Credentials: q.Get("credentials")}
+	if opts.Credentials == credentialsInclude {
+		writeAPIError(w, http.StatusForbidden, errors.New("credentials=include is not served over HTTP, use `xboost export`"))
+		return
+	}
+	if tables := q.Get("tables"); tables != "" {
+		opts.Tables = strings.Split(tables, ",")
+	}
+	if name := q.Get("account"); name != "" {
+		acct, err := findAccount(s.app.db, name)
+		if err != nil {
+			writeAPIError(w, http.StatusNotFound, err)
+			return
+		}
+		opts.AccountUserID = acct.UserID
+	}
+
+	payload, err := BuildExport(s.app.db, opts)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, payload)
+}
+
+func (s *apiServer) handleFetch(w http.ResponseWriter, r *http.Request, acct Account) {
+	var msg string
+	var err error
+	switch r.PathValue("target") {
+	case "following":
+		msg, err = s.app.fetchFollowingForAccount(r.Context(), acct)
+	case "followers":
+		msg, err = s.app.fetchFollowersForAccount(r.Context(), acct)
+	case "lists":
+		msg, err = s.app.fetchListsForAccount(r.Context(), acct)
+	default:
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("unknown fetch target %q", r.PathValue("target")))
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, fetchResult{Account: acct.Username, Message: msg})
+}
+
+// userQuery is the filtering, sorting and paging of a user listing:
+//
+//	q=text            substring of username, name or description
+//	verified=true     only (un)verified users
+//	min_followers=N   followers_count >= N (also max_followers)
+//	sort=field&dir=asc|desc  any field sortUsers supports
+//	limit=N&offset=N  page; limit 0 or above 1000 means 1000
+type userQuery struct {
+	Search       string
+	Verified     *bool
+	MinFollowers int
+	MaxFollowers int
+	Sort         string
+	Dir          string
+	Limit        int
+	Offset       int
+}
+
+var userSortFields = map[string]bool{
+	"followers_count": true, "following_count": true, "tweet_count": true,
+	"listed_count": true, "stability_score": true, "username": true, "name": true,
+}
+
+func parseUserQuery(r *http.Request) (userQuery, error) {
+	q := r.URL.Query()
+	uq := userQuery{
+		Search: strings.ToLower(strings.TrimSpace(q.Get("q"))),
+		Sort:   q.Get("sort"),
+		Dir:    q.Get("dir"),
+		Limit:  defaultPageLimit,
+	}
+	if uq.Sort != "" && !userSortFields[uq.Sort] {
+		return uq, fmt.Errorf("unknown sort field %q", uq.Sort)
+	}
+	if uq.Dir != "" && uq.Dir != "asc" && uq.Dir != "desc" {
+		return uq, fmt.Errorf("dir must be asc or desc")
+	}
+	if v := q.Get("verified"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return uq, fmt.Errorf("invalid verified %q", v)
+		}
+		uq.Verified = &b
+	}
+
+	for _, p := range []struct {
+		name string
+		dest *int
+	}{
+		{"min_followers", &uq.MinFollowers},
+		{"max_followers", &uq.MaxFollowers},
+		{"limit", &uq.Limit},
+		{"offset", &uq.Offset},
+	} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return uq, fmt.Errorf("invalid %s %q", p.name, v)
+		}
+		*p.dest = n
+	}
+	if uq.Limit == 0 || uq.Limit > maxPageLimit {
+		uq.Limit = maxPageLimit
+	}
+	return uq, nil
+}
+
+func (uq userQuery) match(u FollowingUser) bool {
+	if uq.Search != "" &&
+		!strings.Contains(strings.ToLower(u.Username), uq.Search) &&
+		!strings.Contains(strings.ToLower(u.Name), uq.Search) &&
+		!strings.Contains(strings.ToLower(u.Description), uq.Search) {
+		return false
+	}
+	if uq.Verified != nil && u.Verified != *uq.Verified {
+		return false
+	}
+	if u.FollowersCount < uq.MinFollowers {
+		return false
+	}
+	if uq.MaxFollowers > 0 && u.FollowersCount > uq.MaxFollowers {
+		return false
+	}
+	return true
+}
+
+// userListPage is one page of a filtered, sorted user listing.
+type userListPage struct {
+	Items  []FollowingUser `json:"items"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+func (s *apiServer) writeUserPage(w http.ResponseWriter, r *http.Request, users []FollowingUser) {
+	uq, err := parseUserQuery(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filtered := make([]FollowingUser, 0, len(users))
+	for _, u := range users {
+		if uq.match(u) {
+			filtered = append(filtered, u)
+		}
+	}
+	if uq.Sort != "" {
+		sortUsers(filtered, uq.Sort, uq.Dir)
+	}
+
+	page := userListPage{Total: len(filtered), Limit: uq.Limit, Offset: uq.Offset}
+	start := min(uq.Offset, len(filtered))
+	end := min(start+uq.Limit, len(filtered))
+	page.Items = filtered[start:end]
+	writeAPIJSON(w, http.StatusOK, page)
+}
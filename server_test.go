@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// apiGet sends an authorized GET to the test server unless token is empty.
+func apiGet(t *testing.T, srv *httptest.Server, path, host, token string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "" {
+		req.Host = host
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAPIServerAuthorization(t *testing.T) {
+	a, _ := newTestApp(t)
+	s, err := newAPIServer(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	tests := []struct {
+		name, path, host, token string
+		want                    int
+	}{
+		{"valid token", "/api/following", "", s.token, http.StatusOK},
+		{"no token", "/api/following", "", "", http.StatusUnauthorized},
+		{"wrong token", "/api/following", "", "not-" + s.token, http.StatusUnauthorized},
+		{"localhost host", "/api/accounts", "localhost:8787", s.token, http.StatusOK},
+		{"rebound host", "/api/accounts", "evil.example:8787", s.token, http.StatusForbidden},
+		{"credentials over HTTP", "/api/export?credentials=include", "", s.token, http.StatusForbidden},
+		{"redacted export", "/api/export", "", s.token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := apiGet(t, srv, tt.path, tt.host, tt.token); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
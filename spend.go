@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"go-twitter-follower/gen"
+)
+
+// defaultPrices is the X API v2 pay-per-use price per resource read, seeded
+// into api_prices on first start. Edit the table (or SetPrice) to change it.
+var defaultPrices = map[string]float64{
+	endpointFollowing:   25.0 / 2200, // $25 / 2.2K following
+	endpointFollowers:   14.0 / 2000, // $14 / 2K followers
+	endpointListMembers: 1.0 / 100,   // $1 / 100 list accounts
+	endpointOwnedLists:  1.0 / 100,
+	endpointUserByName:  1.0 / 100,
+	endpointUserTweets:  1.0 / 200, // $0.005 / post read
+}
+
+// SpendLedger writes one api_calls row per HTTP request sent to the X API.
+type SpendLedger struct {
+	mu sync.Mutex
+	db *sql.DB
+}
+
+var spendLedger = &SpendLedger{}
+
+func (l *SpendLedger) SetDB(db *sql.DB) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.db = db
+}
+
+func (l *SpendLedger) Record(call APICall) {
+	l.mu.Lock()
+	db := l.db
+	l.mu.Unlock()
+	if db == nil {
+		return
+	}
+
+	if call.StatusCode == http.StatusOK {
+		call.Cost = float64(call.Resources) * GetUnitPrice(db, call.Endpoint)
+	}
+	if err := SaveAPICall(db, call); err != nil {
+		log.Printf("Warning: failed to record API call: %v", err)
+	}
+}
+
+// ledgerDoer records every request it sends, including retried attempts, with
+// the number of resources returned and their cost.
+type ledgerDoer struct {
+	next          gen.HttpRequestDoer
+	ledger        *SpendLedger
+	accountUserID string
+	tokenKey      string
+}
+
+func newLedgerDoer(next gen.HttpRequestDoer, accountUserID, token string) *ledgerDoer {
+	return &ledgerDoer{
+		next:          next,
+		ledger:        spendLedger,
+		accountUserID: accountUserID,
+		tokenKey:      tokenFingerprint(token),
+	}
+}
+
+func (d *ledgerDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	d.ledger.Record(APICall{
+		Endpoint:      normalizeEndpoint(req.Method, req.URL.Path),
+		AccountUserID: d.accountUserID,
+		TokenKey:      requestTokenKey(req, d.tokenKey),
+		StatusCode:    res.StatusCode,
+		Resources:     countResources(body),
+	})
+	return res, nil
+}
+
+// countResources returns how many objects a response's data field holds.
+func countResources(body []byte) int {
+	var payload struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Data) == 0 {
+		return 0
+	}
+	switch payload.Data[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(payload.Data, &items); err != nil {
+			return 0
+		}
+		return len(items)
+	case '{':
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestLedgerRecordsEveryAttempt(t *testing.T) {
+	a, fake := newTestApp(t)
+	acct := testAccount(t, a)
+	client, err := NewAccountClient(acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.FailNext(http.StatusServiceUnavailable)
+	if _, _, err := GetFollowing(context.Background(), client, fakeAccountID, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	calls, err := a.db.Query(`SELECT endpoint, account_user_id, token_key, status_code, resources, cost FROM api_calls ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer calls.Close()
+	price := GetUnitPrice(a.db, endpointFollowing)
+	want := []APICall{
+		{StatusCode: http.StatusServiceUnavailable},
+		{StatusCode: http.StatusOK, Resources: 100, Cost: 100 * price},
+	}
+	var n int
+	for ; calls.Next(); n++ {
+		var c APICall
+		if err := calls.Scan(&c.Endpoint, &c.AccountUserID, &c.TokenKey, &c.StatusCode, &c.Resources, &c.Cost); err != nil {
+			t.Fatal(err)
+		}
+		if n >= len(want) {
+			continue
+		}
+		if c.Endpoint != endpointFollowing || c.AccountUserID != fakeAccountID || c.TokenKey != tokenFingerprint(acct.BearerToken) {
+			t.Errorf("call %d attributed to %s/%s/%s", n, c.Endpoint, c.AccountUserID, c.TokenKey)
+		}
+		if c.StatusCode != want[n].StatusCode || c.Resources != want[n].Resources || math.Abs(c.Cost-want[n].Cost) > 1e-9 {
+			t.Errorf("call %d = HTTP %d, %d resources, $%.4f; want HTTP %d, %d, $%.4f",
+				n, c.StatusCode, c.Resources, c.Cost, want[n].StatusCode, want[n].Resources, want[n].Cost)
+		}
+	}
+	if n != len(want) {
+		t.Errorf("ledger has %d calls, want %d", n, len(want))
+	}
+}
+
+func TestCountResources(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"data": [{"id": "1"}, {"id": "2"}]}`, 2},
+		{`{"data": {"id": "1"}}`, 1},
+		{`{"data": []}`, 0},
+		{`{"data": null}`, 0},
+		{`{"meta": {"result_count": 0}}`, 0},
+		{`not json`, 0},
+	}
+	for _, tt := range tests {
+		if got := countResources([]byte(tt.body)); got != tt.want {
+			t.Errorf("countResources(%s) = %d, want %d", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestGetSpendSummary(t *testing.T) {
+	db := newTestDB(t)
+	for _, c := range []APICall{
+		{Endpoint: endpointFollowing, AccountUserID: "1", Resources: 100, Cost: 1.0, CreatedAt: "2026-03-01T10:00:00Z"},
+		{Endpoint: endpointFollowing, AccountUserID: "2", Resources: 50, Cost: 0.5, CreatedAt: "2026-03-02T10:00:00Z"},
+		{Endpoint: endpointFollowers, AccountUserID: "1", Resources: 10, Cost: 0.25, CreatedAt: "2026-03-02T11:00:00Z"},
+		{Endpoint: endpointFollowers, AccountUserID: "1", StatusCode: 503, CreatedAt: "2026-03-02T11:00:01Z"},
+		{Endpoint: endpointFollowers, AccountUserID: "1", Resources: 99, Cost: 9, CreatedAt: "2026-02-28T23:00:00Z"},
+	} {
+		if err := SaveAPICall(db, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := GetSpendSummary(db, spendByEndpoint, "2026-03-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]SpendRow{
+		endpointFollowers: {Requests: 2, Resources: 10, Cost: 0.25},
+		endpointFollowing: {Requests: 2, Resources: 150, Cost: 1.5},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d endpoints, want %d: %+v", len(rows), len(want), rows)
+	}
+	for _, r := range rows {
+		w := want[r.Key]
+		if r.Requests != w.Requests || r.Resources != w.Resources || math.Abs(r.Cost-w.Cost) > 1e-9 {
+			t.Errorf("%s = %+v, want %+v", r.Key, r, w)
+		}
+	}
+
+	days, err := GetSpendSummary(db, spendByDay, "2026-03-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 || days[0].Key != "2026-03-01" || days[1].Requests != 3 || math.Abs(days[1].Cost-0.75) > 1e-9 {
+		t.Errorf("by day = %+v, want 2026-03-01 and 3 calls for $0.75 on 2026-03-02", days)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+)
+
+// RelationshipEvent is a change in one relation between the account and a
+// user, detected between two consecutive snapshots. Initial marks a user who
+// was already present in the first snapshot, so the real follow time is unknown.
+type RelationshipEvent struct {
+	Relation string `json:"relation"`
+	Type     string `json:"type"`
+	At       string `json:"at"`
+	Initial  bool   `json:"initial,omitempty"`
+}
+
+const (
+	relationFollower  = "follower"
+	relationFollowing = "following"
+
+	eventFollowed   = "followed"
+	eventUnfollowed = "unfollowed"
+)
+
+// UserTimeline is the full follow/unfollow history of one user.
+type UserTimeline struct {
+	User      *FollowingUser      `json:"user"`
+	Events    []RelationshipEvent `json:"events"`
+	Follows   int                 `json:"follows"`
+	Unfollows int                 `json:"unfollows"`
+}
+
+// ChurnUser is a follower who unfollowed the account at least once.
+type ChurnUser struct {
+	FollowingUser
+	Follows   int    `json:"follows"`
+	Unfollows int    `json:"unfollows"`
+	LastEvent string `json:"last_event"`
+	LastAt    string `json:"last_at"`
+}
+
+// buildEvents walks snapshots (oldest first) and emits an event whenever
+// presence flips. A user absent from the latest snapshot ends unfollowed.
+func buildEvents(relation string, snapshots []string, present map[string]bool) []RelationshipEvent {
+	var events []RelationshipEvent
+	was := false
+	for i, at := range snapshots {
+		is := present[at]
+		switch {
+		case is && !was:
+			events = append(events, RelationshipEvent{Relation: relation, Type: eventFollowed, At: at, Initial: i == 0})
+		case !is && was:
+			events = append(events, RelationshipEvent{Relation: relation, Type: eventUnfollowed, At: at})
+		}
+		was = is
+	}
+	return events
+}
+
+// BuildUserTimeline merges follower and following events for targetUserId.
+func BuildUserTimeline(db *sql.DB, sourceUserId, targetUserId string) (UserTimeline, error) {
+	var tl UserTimeline
+
+	for _, rel := range []struct{ table, relation string }{
+		{"followers_snapshots", relationFollower},
+		{"following_snapshots", relationFollowing},
+	} {
+		snapshots, err := GetSnapshotTimestamps(db, rel.table, sourceUserId)
+		if err != nil {
+			return tl, err
+		}
+		present, err := GetTargetSnapshotTimes(db, rel.table, sourceUserId, targetUserId)
+		if err != nil {
+			return tl, err
+		}
+
+		times := make([]string, len(snapshots))
+		for i, s := range snapshots {
+			times[len(snapshots)-1-i] = s.FetchedAt
+		}
+		tl.Events = append(tl.Events, buildEvents(rel.relation, times, present)...)
+	}
+
+	sort.SliceStable(tl.Events, func(i, j int) bool { return tl.Events[i].At < tl.Events[j].At })
+	for _, e := range tl.Events {
+		if e.Relation != relationFollower {
+			continue
+		}
+		if e.Type == eventFollowed {
+			tl.Follows++
+		} else {
+			tl.Unfollows++
+		}
+	}
+
+	users, err := GetUsersByIDs(db, []string{targetUserId})
+	if err != nil {
+		return tl, err
+	}
+	if len(users) > 0 {
+		tl.User = &users[0]
+	}
+	return tl, nil
+}
+
+// FindFollowerChurners returns every follower who unfollowed at least once,
+// most unfollows first.
+func FindFollowerChurners(db *sql.DB, sourceUserId string) ([]ChurnUser, error) {
+	snapshots, err := GetSnapshotTimestamps(db, "followers_snapshots", sourceUserId)
+	if err != nil {
+		return nil, err
+	}
+	times := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		times[len(snapshots)-1-i] = s.FetchedAt
+	}
+
+	presence, err := GetSnapshotPresence(db, "followers_snapshots", sourceUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	churn := make(map[string]ChurnUser)
+	var ids []string
+	for target, present := range presence {
+		events := buildEvents(relationFollower, times, present)
+		var c ChurnUser
+		for _, e := range events {
+			if e.Type == eventFollowed {
+				c.Follows++
+			} else {
+				c.Unfollows++
+			}
+		}
+		if c.Unfollows == 0 {
+			continue
+		}
+		last := events[len(events)-1]
+		c.LastEvent, c.LastAt = last.Type, last.At
+		churn[target] = c
+		ids = append(ids, target)
+	}
+
+	users, err := GetUsersByIDs(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ChurnUser, 0, len(users))
+	for _, u := range users {
+		c := churn[u.Id]
+		c.FollowingUser = u
+		result = append(result, c)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Unfollows != result[j].Unfollows {
+			return result[i].Unfollows > result[j].Unfollows
+		}
+		return result[i].Follows > result[j].Follows
+	})
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// insertSnapshot stores one snapshot of source at fetchedAt listing targets,
+// adding any target missing from users.
+func insertSnapshot(t *testing.T, db *sql.DB, table, source, fetchedAt string, targets ...string) {
+	t.Helper()
+	for _, target := range targets {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO users (id, username, followers_count) VALUES (?, ?, 10)`, target, "user_"+target); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO `+table+` (source_user_id, target_user_id, fetched_at) VALUES (?, ?, ?)`,
+			source, target, fetchedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuildEvents(t *testing.T) {
+	times := []string{"t1", "t2", "t3", "t4"}
+	events := buildEvents(relationFollower, times, map[string]bool{"t1": true, "t3": true})
+
+	want := []RelationshipEvent{
+		{Relation: relationFollower, Type: eventFollowed, At: "t1", Initial: true},
+		{Relation: relationFollower, Type: eventUnfollowed, At: "t2"},
+		{Relation: relationFollower, Type: eventFollowed, At: "t3"},
+		{Relation: relationFollower, Type: eventUnfollowed, At: "t4"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestUserTimelineAndChurners(t *testing.T) {
+	db := newTestDB(t)
+	const acct = "1"
+	insertSnapshot(t, db, "followers_snapshots", acct, "2026-01-01T00:00:00Z", "a", "b")
+	insertSnapshot(t, db, "followers_snapshots", acct, "2026-01-02T00:00:00Z", "b")
+	insertSnapshot(t, db, "followers_snapshots", acct, "2026-01-03T00:00:00Z", "a", "b")
+	insertSnapshot(t, db, "following_snapshots", acct, "2026-01-02T00:00:00Z", "a")
+
+	tl, err := BuildUserTimeline(db, acct, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.User == nil || tl.User.Id != "a" {
+		t.Fatalf("timeline user = %+v, want a", tl.User)
+	}
+	if tl.Follows != 2 || tl.Unfollows != 1 {
+		t.Errorf("follows %d, unfollows %d; want 2 and 1", tl.Follows, tl.Unfollows)
+	}
+	if len(tl.Events) != 4 {
+		t.Errorf("got %d events, want 3 follower events and 1 following event", len(tl.Events))
+	}
+	for i := 1; i < len(tl.Events); i++ {
+		if tl.Events[i].At < tl.Events[i-1].At {
+			t.Errorf("events out of order: %+v", tl.Events)
+		}
+	}
+
+	churners, err := FindFollowerChurners(db, acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(churners) != 1 || churners[0].Id != "a" || churners[0].Unfollows != 1 || churners[0].LastEvent != eventFollowed {
+		t.Errorf("churners = %+v, want only a, back as a follower", churners)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"go-twitter-follower/gen"
@@ -12,22 +13,60 @@ import (
 	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
 )
 
-const (
-	scheme = "https"
-	host   = "api.twitter.com"
+const defaultAPIBaseURL = "https://api.twitter.com"
+
+// https://docs.x.com/x-api/fundamentals/rate-limits
+// GET /2/users/:id/following | 300 reqs/15 minutes (per app & per user)
+// Minimum spacing between pages; x-rate-limit-* headers are honoured on top (ratelimit.go).
+// A var so tests against the fake API can shorten it.
+var rate_limit = 1000 * time.Millisecond * 3 // 300 per 15 min
 
-	// https://docs.x.com/x-api/fundamentals/rate-limits
-	// GET /2/users/:id/following | 300 reqs/15 minutes (per app & per user)
-	rate_limit = 1000 * time.Millisecond * 3 // 300 per 15 min
+// Endpoint route templates, as used in fetch_logs, fetch_jobs and api_prices.
+const (
+	endpointFollowing   = "GET /2/users/:id/following"
+	endpointFollowers   = "GET /2/users/:id/followers"
+	endpointOwnedLists  = "GET /2/users/:id/owned_lists"
+	endpointListMembers = "GET /2/lists/:id/members"
+	endpointUserByName  = "GET /2/users/by/username/:username"
+	endpointFollow      = "POST /2/users/:id/following"
+	endpointUserTweets  = "GET /2/users/:id/tweets"
 )
 
+// apiBaseURL is where every client sends requests; X_API_BASE_URL points it
+// at another server such as the fake API (fakeapi.go).
+var apiBaseURL = defaultAPIBaseURL
+
+func SetAPIBaseURL(baseURL string) {
+	if baseURL == "" {
+		baseURL = defaultAPIBaseURL
+	}
+	apiBaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// NewAuthClient is for lookups made before the account is known; their spend
+// is recorded without an account until AttributeAPICalls assigns it.
 func NewAuthClient(bearerToken string) (*gen.ClientWithResponses, error) {
+	return newClient(bearerToken, "")
+}
+
+// NewAccountClient is NewAuthClient with API spend attributed to acct.
+// Endpoints that need user context use acct's OAuth2 token when it has one,
+// and their rate limits and spend are keyed by that token.
+func NewAccountClient(acct Account) (*gen.ClientWithResponses, error) {
+	return newClient(acct.BearerToken, acct.UserID)
+}
+
+func newClient(bearerToken, accountUserID string) (*gen.ClientWithResponses, error) {
 	bearerTokenProvider, bearerTokenProviderErr := securityprovider.NewSecurityProviderBearerToken(bearerToken)
 	if bearerTokenProviderErr != nil {
 		return nil, fmt.Errorf("creating bearer token provider: %w", bearerTokenProviderErr)
 	}
 
-	client, err := gen.NewClientWithResponses(fmt.Sprintf("%s://%s", scheme, host), gen.WithRequestEditorFn(bearerTokenProvider.Intercept))
+	doer := newRateLimitedDoer(withCassette(newLedgerDoer(&http.Client{}, accountUserID, bearerToken)), bearerToken)
+	client, err := gen.NewClientWithResponses(apiBaseURL,
+		gen.WithHTTPClient(doer),
+		gen.WithRequestEditorFn(bearerTokenProvider.Intercept),
+		gen.WithRequestEditorFn(userContextEditor(accountUserID)))
 	if err != nil {
 		return nil, fmt.Errorf("creating client: %w", err)
 	}
@@ -35,21 +74,60 @@ func NewAuthClient(bearerToken string) (*gen.ClientWithResponses, error) {
 	return client, nil
 }
 
-func ResolveUsername(client *gen.ClientWithResponses, username string) (string, error) {
-	res, err := client.FindUserByUsernameWithResponse(context.Background(), username, &gen.FindUserByUsernameParams{
-		UserFields: nil,
+// NewUserContextClient signs requests with OAuth1 user context instead of the
+// app bearer token. Spend is attributed to accountUserID.
+func NewUserContextClient(creds OAuth1Credentials, accountUserID string) (*gen.ClientWithResponses, error) {
+	if !creds.Complete() {
+		return nil, fmt.Errorf("OAuth1 user credentials missing (API_KEY, API_KEY_SECRET and an account login or ACCESS_TOKEN, ACCESS_TOKEN_SECRET)")
+	}
+
+	doer := newRateLimitedDoer(withCassette(newLedgerDoer(NewOAuth1HTTPClient(creds), accountUserID, creds.AccessToken)), creds.AccessToken)
+	client, err := gen.NewClientWithResponses(apiBaseURL, gen.WithHTTPClient(doer))
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+
+	return client, nil
+}
+
+func ResolveUsername(ctx context.Context, client *gen.ClientWithResponses, username string) (string, error) {
+	user, err := LookupUser(ctx, client, username)
+	if err != nil {
+		return "", err
+	}
+	return user.Id, nil
+}
+
+// LookupUser fetches a user with public_metrics, so follower/following counts
+// are known (e.g. for cost estimates) before any paginated fetch.
+func LookupUser(ctx context.Context, client *gen.ClientWithResponses, username string) (*gen.User, error) {
+	userFields := gen.UserFieldsParameter{
+		"public_metrics",
+		"description",
+		"created_at",
+		"verified",
+		"verified_type",
+		"profile_image_url",
+		"location",
+	}
+	res, err := client.FindUserByUsernameWithResponse(ctx, username, &gen.FindUserByUsernameParams{
+		UserFields: &userFields,
 	})
 	if err != nil {
-		return "", fmt.Errorf("finding user by username: %w", err)
+		return nil, fmt.Errorf("finding user by username: %w", err)
 	}
 	if res.StatusCode() != http.StatusOK {
-		return "", fmt.Errorf("API error: %s: %s", *res.JSONDefault.Status, *res.JSONDefault.Detail)
+		return nil, apiError(res.StatusCode(), res.JSONDefault, res.Body)
+	}
+	if res.JSON200 == nil || res.JSON200.Data == nil {
+		return nil, fmt.Errorf("user @%s not found", username)
 	}
 
-	return res.JSON200.Data.Id, nil
+	return res.JSON200.Data, nil
 }
 
-func GetFollowing(client *gen.ClientWithResponses, userId string, pagination_token *string) (*[]gen.User, *string, error) {
+// GetFollowing fetches one page; maxResults 0 leaves the API default (100).
+func GetFollowing(ctx context.Context, client *gen.ClientWithResponses, userId string, pagination_token *string, maxResults int) (*[]gen.User, *string, error) {
 	userFields := gen.UserFieldsParameter{
 		"public_metrics",
 		"description",
@@ -69,29 +147,36 @@ func GetFollowing(client *gen.ClientWithResponses, userId string, pagination_tok
 	if pagination_token != nil {
 		params.PaginationToken = pagination_token
 	}
+	if maxResults > 0 {
+		params.MaxResults = maxResultsParam(maxResults)
+	}
 
 	log.Printf("[fetch] GET /2/users/%s/following (pagination: %v)", userId, pagination_token != nil)
-	res, err := client.UsersIdFollowingWithResponse(context.Background(), userId, params)
+	res, err := client.UsersIdFollowingWithResponse(ctx, userId, params)
 	if err != nil {
 		return nil, nil, fmt.Errorf("API request failed: %w", err)
 	}
 	log.Printf("[fetch] Response: HTTP %d (%d bytes)", res.StatusCode(), len(res.Body))
 	if res.StatusCode() != http.StatusOK {
 		log.Printf("[fetch] Error body: %s", string(res.Body))
-		if res.JSONDefault != nil && res.JSONDefault.Status != nil && res.JSONDefault.Detail != nil {
-			return nil, nil, fmt.Errorf("API error %d: %s: %s", res.StatusCode(), *res.JSONDefault.Status, *res.JSONDefault.Detail)
-		}
-		return nil, nil, fmt.Errorf("API error %d: %s", res.StatusCode(), string(res.Body))
+		return nil, nil, apiError(res.StatusCode(), res.JSONDefault, res.Body)
 	}
-	if res.JSON200 == nil || res.JSON200.Data == nil {
+	if res.JSON200 == nil {
 		return nil, nil, fmt.Errorf("API returned empty response")
 	}
 
-	next_token := res.JSON200.Meta.NextToken
+	var next_token *string
+	if res.JSON200.Meta != nil && res.JSON200.Meta.NextToken != nil {
+		next_token = res.JSON200.Meta.NextToken
+	}
+	// Users who follow no one return no data field at all.
+	if res.JSON200.Data == nil {
+		return &[]gen.User{}, nil, nil
+	}
 	return res.JSON200.Data, next_token, nil
 }
 
-func GetOwnedLists(client *gen.ClientWithResponses, userId string) ([]gen.List, error) {
+func GetOwnedLists(ctx context.Context, client *gen.ClientWithResponses, userId string) ([]gen.List, error) {
 	listFields := gen.ListFieldsParameter{
 		"description",
 		"member_count",
@@ -105,17 +190,14 @@ func GetOwnedLists(client *gen.ClientWithResponses, userId string) ([]gen.List,
 	}
 
 	log.Printf("[fetch] GET /2/users/%s/owned_lists", userId)
-	res, err := client.ListUserOwnedListsWithResponse(context.Background(), userId, params)
+	res, err := client.ListUserOwnedListsWithResponse(ctx, userId, params)
 	if err != nil {
 		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	log.Printf("[fetch] Response: HTTP %d (%d bytes)", res.StatusCode(), len(res.Body))
 	if res.StatusCode() != http.StatusOK {
 		log.Printf("[fetch] Error body: %s", string(res.Body))
-		if res.JSONDefault != nil && res.JSONDefault.Status != nil && res.JSONDefault.Detail != nil {
-			return nil, fmt.Errorf("API error %d: %s: %s", res.StatusCode(), *res.JSONDefault.Status, *res.JSONDefault.Detail)
-		}
-		return nil, fmt.Errorf("API error %d: %s", res.StatusCode(), string(res.Body))
+		return nil, apiError(res.StatusCode(), res.JSONDefault, res.Body)
 	}
 	if res.JSON200 == nil || res.JSON200.Data == nil {
 		return nil, nil
@@ -123,7 +205,7 @@ func GetOwnedLists(client *gen.ClientWithResponses, userId string) ([]gen.List,
 	return *res.JSON200.Data, nil
 }
 
-func GetListMembers(client *gen.ClientWithResponses, listId string, paginationToken *string) (*[]gen.User, *string, error) {
+func GetListMembers(ctx context.Context, client *gen.ClientWithResponses, listId string, paginationToken *string, maxResults int) (*[]gen.User, *string, error) {
 	userFields := gen.UserFieldsParameter{
 		"public_metrics",
 		"description",
@@ -139,19 +221,19 @@ func GetListMembers(client *gen.ClientWithResponses, listId string, paginationTo
 	if paginationToken != nil {
 		params.PaginationToken = paginationToken
 	}
+	if maxResults > 0 {
+		params.MaxResults = maxResultsParam(maxResults)
+	}
 
 	log.Printf("[fetch] GET /2/lists/%s/members (pagination: %v)", listId, paginationToken != nil)
-	res, err := client.ListGetMembersWithResponse(context.Background(), listId, params)
+	res, err := client.ListGetMembersWithResponse(ctx, listId, params)
 	if err != nil {
 		return nil, nil, fmt.Errorf("API request failed: %w", err)
 	}
 	log.Printf("[fetch] Response: HTTP %d (%d bytes)", res.StatusCode(), len(res.Body))
 	if res.StatusCode() != http.StatusOK {
 		log.Printf("[fetch] Error body: %s", string(res.Body))
-		if res.JSONDefault != nil && res.JSONDefault.Status != nil && res.JSONDefault.Detail != nil {
-			return nil, nil, fmt.Errorf("API error %d: %s: %s", res.StatusCode(), *res.JSONDefault.Status, *res.JSONDefault.Detail)
-		}
-		return nil, nil, fmt.Errorf("API error %d: %s", res.StatusCode(), string(res.Body))
+		return nil, nil, apiError(res.StatusCode(), res.JSONDefault, res.Body)
 	}
 	if res.JSON200 == nil || res.JSON200.Data == nil {
 		return nil, nil, nil
@@ -164,43 +246,7 @@ func GetListMembers(client *gen.ClientWithResponses, listId string, paginationTo
 	return res.JSON200.Data, nextToken, nil
 }
 
-// FetchAllListMembers fetches the complete list member list with pagination and rate limiting.
-func FetchAllListMembers(client *gen.ClientWithResponses, listId string) ([]gen.User, error) {
-	all := make([]gen.User, 0)
-
-	f, paginationToken, err := GetListMembers(client, listId, nil)
-	if err != nil {
-		return nil, err
-	}
-	if f != nil {
-		all = append(all, *f...)
-	}
-	log.Printf("Counting list members: %d", len(all))
-
-	if paginationToken == nil {
-		return all, nil
-	}
-
-	rateLimiter := time.Tick(rate_limit)
-	for range rateLimiter {
-		f, paginationToken, err = GetListMembers(client, listId, paginationToken)
-		if err != nil {
-			return nil, err
-		}
-		if f != nil {
-			all = append(all, *f...)
-		}
-		log.Printf("Counting list members: %d", len(all))
-
-		if paginationToken == nil {
-			break
-		}
-	}
-
-	return all, nil
-}
-
-func GetFollowers(client *gen.ClientWithResponses, userId string, paginationToken *string) (*[]gen.User, *string, error) {
+func GetFollowers(ctx context.Context, client *gen.ClientWithResponses, userId string, paginationToken *string, maxResults int) (*[]gen.User, *string, error) {
 	userFields := gen.UserFieldsParameter{
 		"public_metrics",
 		"description",
@@ -216,21 +262,21 @@ func GetFollowers(client *gen.ClientWithResponses, userId string, paginationToke
 	if paginationToken != nil {
 		params.PaginationToken = paginationToken
 	}
+	if maxResults > 0 {
+		params.MaxResults = maxResultsParam(maxResults)
+	}
 
 	log.Printf("[fetch] GET /2/users/%s/followers (pagination: %v)", userId, paginationToken != nil)
-	res, err := client.UsersIdFollowersWithResponse(context.Background(), userId, params)
+	res, err := client.UsersIdFollowersWithResponse(ctx, userId, params)
 	if err != nil {
 		return nil, nil, fmt.Errorf("API request failed: %w", err)
 	}
 	log.Printf("[fetch] Response: HTTP %d (%d bytes)", res.StatusCode(), len(res.Body))
 	if res.StatusCode() != http.StatusOK {
 		log.Printf("[fetch] Error body: %s", string(res.Body))
-		if res.JSONDefault != nil && res.JSONDefault.Status != nil && res.JSONDefault.Detail != nil {
-			return nil, nil, fmt.Errorf("API error %d: %s: %s", res.StatusCode(), *res.JSONDefault.Status, *res.JSONDefault.Detail)
-		}
-		return nil, nil, fmt.Errorf("API error %d: %s", res.StatusCode(), string(res.Body))
+		return nil, nil, apiError(res.StatusCode(), res.JSONDefault, res.Body)
 	}
-	if res.JSON200 == nil || res.JSON200.Data == nil {
+	if res.JSON200 == nil {
 		return nil, nil, fmt.Errorf("API returned empty response")
 	}
 
@@ -238,74 +284,150 @@ func GetFollowers(client *gen.ClientWithResponses, userId string, paginationToke
 	if res.JSON200.Meta != nil && res.JSON200.Meta.NextToken != nil {
 		nextToken = res.JSON200.Meta.NextToken
 	}
+	// Users without followers return no data field at all.
+	if res.JSON200.Data == nil {
+		return &[]gen.User{}, nil, nil
+	}
 	return res.JSON200.Data, nextToken, nil
 }
 
-// FetchAllFollowers fetches the complete followers list with pagination and rate limiting.
-func FetchAllFollowers(client *gen.ClientWithResponses, userId string) ([]gen.User, error) {
-	all := make([]gen.User, 0)
+// GetUserTweets fetches one page of a user's original posts (no retweets or
+// replies) with public_metrics, at most maxResults (0 means defaultPageSize).
+func GetUserTweets(ctx context.Context, client *gen.ClientWithResponses, userId string, paginationToken *string, maxResults int) (*[]gen.Tweet, *string, error) {
+	tweetFields := gen.TweetFieldsParameter{
+		"created_at",
+		"public_metrics",
+	}
+	exclude := []gen.UsersIdTweetsParamsExclude{gen.Replies, gen.Retweets}
+	if maxResults <= 0 {
+		maxResults = defaultPageSize
+	}
+	params := &gen.UsersIdTweetsParams{
+		MaxResults:      maxResultsParam(maxResults),
+		PaginationToken: paginationToken,
+		Exclude:         &exclude,
+		TweetFields:     &tweetFields,
+	}
 
-	f, paginationToken, err := GetFollowers(client, userId, nil)
+	log.Printf("[fetch] GET /2/users/%s/tweets (pagination: %v)", userId, paginationToken != nil)
+	res, err := client.UsersIdTweetsWithResponse(ctx, userId, params)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("API request failed: %w", err)
 	}
-	if f != nil {
-		all = append(all, *f...)
+	log.Printf("[fetch] Response: HTTP %d (%d bytes)", res.StatusCode(), len(res.Body))
+	if res.StatusCode() != http.StatusOK {
+		log.Printf("[fetch] Error body: %s", string(res.Body))
+		return nil, nil, apiError(res.StatusCode(), res.JSONDefault, res.Body)
+	}
+	if res.JSON200 == nil {
+		return nil, nil, fmt.Errorf("API returned empty response")
 	}
-	log.Printf("Counting followers: %d", len(all))
 
-	if paginationToken == nil {
-		return all, nil
+	var nextToken *string
+	if res.JSON200.Meta != nil && res.JSON200.Meta.NextToken != nil {
+		nextToken = res.JSON200.Meta.NextToken
 	}
+	// Users who never posted return no data field at all.
+	if res.JSON200.Data == nil {
+		return &[]gen.Tweet{}, nil, nil
+	}
+	return res.JSON200.Data, nextToken, nil
+}
 
-	rateLimiter := time.Tick(rate_limit)
-	for range rateLimiter {
-		f, paginationToken, err = GetFollowers(client, userId, paginationToken)
-		if err != nil {
-			return nil, err
-		}
-		if f != nil {
-			all = append(all, *f...)
+// NewUserTweetsPaginator pages through GET /2/users/:id/tweets.
+func NewUserTweetsPaginator(client *gen.ClientWithResponses, userId string) *Paginator[gen.Tweet] {
+	p := NewPaginator("tweets", func(ctx context.Context, token *string, maxResults int) (Page[gen.Tweet], error) {
+		tweets, next, err := GetUserTweets(ctx, client, userId, token, maxResults)
+		page := Page[gen.Tweet]{NextToken: next}
+		if tweets != nil {
+			page.Items = *tweets
 		}
-		log.Printf("Counting followers: %d", len(all))
+		return page, err
+	})
+	p.MinPageSize = minPageSize(endpointUserTweets)
+	return p
+}
 
-		if paginationToken == nil {
-			break
-		}
-	}
+// NewFollowingPaginator pages through GET /2/users/:id/following.
+func NewFollowingPaginator(client *gen.ClientWithResponses, userId string) *Paginator[gen.User] {
+	return NewPaginator("followings", func(ctx context.Context, token *string, maxResults int) (Page[gen.User], error) {
+		users, next, err := GetFollowing(ctx, client, userId, token, maxResults)
+		return userPage(users, next), err
+	})
+}
+
+// NewFollowersPaginator pages through GET /2/users/:id/followers.
+func NewFollowersPaginator(client *gen.ClientWithResponses, userId string) *Paginator[gen.User] {
+	return NewPaginator("followers", func(ctx context.Context, token *string, maxResults int) (Page[gen.User], error) {
+		users, next, err := GetFollowers(ctx, client, userId, token, maxResults)
+		return userPage(users, next), err
+	})
+}
 
-	return all, nil
+// NewListMembersPaginator pages through GET /2/lists/:id/members.
+func NewListMembersPaginator(client *gen.ClientWithResponses, listId string) *Paginator[gen.User] {
+	return NewPaginator("list members", func(ctx context.Context, token *string, maxResults int) (Page[gen.User], error) {
+		users, next, err := GetListMembers(ctx, client, listId, token, maxResults)
+		return userPage(users, next), err
+	})
 }
 
 // FetchAllFollowing fetches the complete following list with pagination and rate limiting.
-func FetchAllFollowing(client *gen.ClientWithResponses, userId string) ([]gen.User, error) {
-	all := make([]gen.User, 0)
+func FetchAllFollowing(ctx context.Context, client *gen.ClientWithResponses, userId string) ([]gen.User, error) {
+	return NewFollowingPaginator(client, userId).All(ctx)
+}
+
+// FetchAllFollowers fetches the complete followers list with pagination and rate limiting.
+func FetchAllFollowers(ctx context.Context, client *gen.ClientWithResponses, userId string) ([]gen.User, error) {
+	return NewFollowersPaginator(client, userId).All(ctx)
+}
+
+// FetchAllListMembers fetches the complete list member list with pagination and rate limiting.
+func FetchAllListMembers(ctx context.Context, client *gen.ClientWithResponses, listId string) ([]gen.User, error) {
+	return NewListMembersPaginator(client, listId).All(ctx)
+}
 
-	// first call without rate limit delay
-	f, pagination_token, err := GetFollowing(client, userId, nil)
+// FollowUser follows targetUserId as sourceUserId, which must be the user the
+// client's OAuth1 token belongs to. pending is true for protected accounts,
+// where the follow waits for approval.
+func FollowUser(ctx context.Context, client *gen.ClientWithResponses, sourceUserId, targetUserId string) (following bool, pending bool, err error) {
+	log.Printf("[follow] POST /2/users/%s/following (target: %s)", sourceUserId, targetUserId)
+	res, err := client.UsersIdFollowWithResponse(ctx, sourceUserId, gen.UsersIdFollowJSONRequestBody{
+		TargetUserId: targetUserId,
+	})
 	if err != nil {
-		return nil, err
+		return false, false, fmt.Errorf("API request failed: %w", err)
 	}
-	all = append(all, *f...)
-	log.Printf("Counting followings: %d", len(all))
-
-	if pagination_token == nil {
-		return all, nil
+	log.Printf("[follow] Response: HTTP %d (%d bytes)", res.StatusCode(), len(res.Body))
+	if res.StatusCode() != http.StatusOK {
+		log.Printf("[follow] Error body: %s", string(res.Body))
+		return false, false, apiError(res.StatusCode(), res.JSONDefault, res.Body)
+	}
+	if res.JSON200 == nil || res.JSON200.Data == nil {
+		return false, false, apiError(res.StatusCode(), nil, res.Body)
 	}
 
-	rate_limiter := time.Tick(rate_limit)
-	for range rate_limiter {
-		f, pagination_token, err = GetFollowing(client, userId, pagination_token)
-		if err != nil {
-			return nil, err
-		}
-		all = append(all, *f...)
-		log.Printf("Counting followings: %d", len(all))
+	data := res.JSON200.Data
+	return data.Following != nil && *data.Following, data.PendingFollow != nil && *data.PendingFollow, nil
+}
 
-		if pagination_token == nil {
-			break
-		}
+func maxResultsParam(n int) *int32 {
+	v := int32(n)
+	return &v
+}
+
+func userPage(users *[]gen.User, nextToken *string) Page[gen.User] {
+	page := Page[gen.User]{NextToken: nextToken}
+	if users != nil {
+		page.Items = *users
 	}
+	return page
+}
 
-	return all, nil
+// apiError builds an error from a non-200 response, preferring the Problem payload.
+func apiError(statusCode int, problem *gen.Problem, body []byte) error {
+	if problem != nil && problem.Status != nil && problem.Detail != nil {
+		return fmt.Errorf("API error %d: %d: %s", statusCode, *problem.Status, *problem.Detail)
+	}
+	return fmt.Errorf("API error %d: %s", statusCode, string(body))
 }